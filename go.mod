@@ -4,8 +4,11 @@ go 1.22.2
 
 require (
 	github.com/go-resty/resty/v2 v2.14.0
+	github.com/klauspost/compress v1.18.0
 	github.com/playwright-community/playwright-go v0.4700.0
+	github.com/robfig/cron/v3 v3.0.1
 	golang.org/x/sync v0.8.0
+	golang.org/x/text v0.16.0
 )
 
 require (
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"time"
+)
+
+const (
+	defaultQueueDir     = "jobs_queue"
+	defaultMaxAttempts  = 3
+	defaultConcurrency  = 1
+	defaultPollInterval = 2 * time.Second
+	defaultLogPeriod    = 3 * time.Second
+)
+
+const (
+	CommandSubmit = "submit"
+	CommandList   = "list"
+	CommandStatus = "status"
+	CommandCancel = "cancel"
+	CommandWorker = "worker"
+)
+
+const (
+	ToolSearchType      = "searchType"
+	ToolQueryVisualizer = "queryVisualizer"
+)
+
+type Config struct {
+	Command      string
+	QueueDir     string
+	JobID        string
+	Tool         string
+	ToolArgs     []string
+	MaxAttempts  int
+	Concurrency  int
+	PollInterval time.Duration
+	LogPeriod    time.Duration
+	// ToolBinaries maps tool name to path of its executable
+	ToolBinaries map[string]string
+}
+
+func Parse() (*Config, error) {
+	if len(os.Args) < 2 {
+		return nil, ErrWrapCommand("")
+	}
+
+	cfg := Config{
+		Command:      os.Args[1],
+		ToolBinaries: make(map[string]string),
+	}
+
+	flagSet := flag.NewFlagSet(cfg.Command, flag.ExitOnError)
+	flagSet.StringVar(&cfg.QueueDir, "queue-dir", defaultQueueDir, "Directory of jobs queue")
+
+	var searchTypeBinary, queryVisualizerBinary string
+
+	switch cfg.Command {
+	case CommandSubmit:
+		flagSet.StringVar(&cfg.Tool, "tool", "", "Tool of job: searchType|queryVisualizer, its flags are passed after --")
+		flagSet.IntVar(&cfg.MaxAttempts, "max-attempts", defaultMaxAttempts, "Number of attempts of failed or crashed job")
+	case CommandWorker:
+		flagSet.IntVar(&cfg.Concurrency, "concurrency", defaultConcurrency, "Number of jobs running at the same time")
+		flagSet.DurationVar(&cfg.PollInterval, "poll-interval", defaultPollInterval, "Period of checking new and canceled jobs")
+		flagSet.DurationVar(&cfg.LogPeriod, "logger-period", defaultLogPeriod, "Period of logger's printing(duration format)")
+		flagSet.StringVar(&searchTypeBinary, "search-type-bin", ToolSearchType, "Path to searchType executable")
+		flagSet.StringVar(&queryVisualizerBinary, "query-visualizer-bin", ToolQueryVisualizer, "Path to queryVisualizer executable")
+	case CommandList, CommandStatus, CommandCancel:
+	default:
+		return nil, ErrWrapCommand(cfg.Command)
+	}
+
+	if err := flagSet.Parse(os.Args[2:]); err != nil {
+		return nil, err
+	}
+
+	cfg.ToolBinaries[ToolSearchType] = searchTypeBinary
+	cfg.ToolBinaries[ToolQueryVisualizer] = queryVisualizerBinary
+
+	if err := cfg.validate(flagSet.Args()); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+func (c *Config) validate(args []string) error {
+	var errSum error
+
+	if c.QueueDir == "" {
+		errSum = errors.Join(errSum, ErrQueueDir)
+	}
+
+	switch c.Command {
+	case CommandSubmit:
+		if c.Tool != ToolSearchType && c.Tool != ToolQueryVisualizer {
+			errSum = errors.Join(errSum, ErrTool)
+		}
+
+		if c.MaxAttempts <= 0 {
+			errSum = errors.Join(errSum, ErrMaxAttempts)
+		}
+
+		c.ToolArgs = args
+	case CommandStatus, CommandCancel:
+		if len(args) != 1 {
+			return errors.Join(errSum, ErrJobID)
+		}
+
+		c.JobID = args[0]
+	case CommandWorker:
+		if c.Concurrency <= 0 {
+			errSum = errors.Join(errSum, ErrConcurrency)
+		}
+
+		if c.PollInterval <= 0 {
+			errSum = errors.Join(errSum, ErrPollInterval)
+		}
+
+		if c.LogPeriod <= 0 {
+			errSum = errors.Join(errSum, ErrLoggerPeriod)
+		}
+	}
+
+	return errSum
+}
@@ -0,0 +1,21 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrCommand      = errors.New("command should be one of submit, list, status, cancel, worker")
+	ErrTool         = errors.New("tool should be one of searchType, queryVisualizer")
+	ErrJobID        = errors.New("job id should be passed as single argument")
+	ErrMaxAttempts  = errors.New("max attempts cannot be less than zero or equal")
+	ErrConcurrency  = errors.New("concurrency cannot be less than zero or equal")
+	ErrPollInterval = errors.New("poll interval cannot be less than zero or equal")
+	ErrLoggerPeriod = errors.New("logger period cannot be less than 1 milli-second")
+	ErrQueueDir     = errors.New("queue dir cannot be empty")
+)
+
+func ErrWrapCommand(command string) error {
+	return fmt.Errorf("command - %q: %w", command, ErrCommand)
+}
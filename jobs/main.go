@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+
+	"wbx-script/jobs/config"
+	"wbx-script/jobs/queue"
+	"wbx-script/jobs/worker"
+	"wbx-script/searchType/logger"
+)
+
+func main() {
+	cfg, err := config.Parse()
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	store, err := queue.NewStore(cfg.QueueDir)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	switch cfg.Command {
+	case config.CommandSubmit:
+		err = submit(cfg, store)
+	case config.CommandList:
+		err = list(store)
+	case config.CommandStatus:
+		err = status(cfg, store)
+	case config.CommandCancel:
+		err = cancel(cfg, store)
+	case config.CommandWorker:
+		err = runWorker(cfg, store)
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func submit(cfg *config.Config, store *queue.Store) error {
+	job, err := store.Submit(cfg.Tool, cfg.ToolArgs, cfg.MaxAttempts)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(job.ID)
+
+	return nil
+}
+
+func list(store *queue.Store) error {
+	jobs, err := store.List()
+
+	if err != nil {
+		return err
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(writer, "ID\tTOOL\tSTATUS\tATTEMPTS\tCREATED\tARGS")
+
+	for _, job := range jobs {
+		fmt.Fprintf(
+			writer, "%s\t%s\t%s\t%d/%d\t%s\t%s\n",
+			job.ID, job.Tool, job.Status, job.Attempts, job.MaxAttempts,
+			job.CreatedAt.Format("2006-01-02 15:04:05"), strings.Join(job.Args, " "),
+		)
+	}
+
+	return writer.Flush()
+}
+
+func printJob(job queue.Job) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(job)
+}
+
+func status(cfg *config.Config, store *queue.Store) error {
+	job, err := store.Get(cfg.JobID)
+
+	if err != nil {
+		return err
+	}
+
+	return printJob(job)
+}
+
+func cancel(cfg *config.Config, store *queue.Store) error {
+	job, err := store.Cancel(cfg.JobID)
+
+	if err != nil {
+		return err
+	}
+
+	if job.Status != queue.StatusCanceled {
+		fmt.Printf("Job %q is running, it will be stopped by worker\n", job.ID)
+		return nil
+	}
+
+	return printJob(job)
+}
+
+func runWorker(cfg *config.Config, store *queue.Store) error {
+	logger.GlobalLogger = logger.NewLogger(
+		slog.New(slog.NewTextHandler(os.Stdout, nil)),
+		cfg.LogPeriod,
+	)
+
+	logger.GlobalLogger.Run()
+
+	defer logger.GlobalLogger.Stop()
+
+	signalCtx, signalStop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+
+	defer signalStop()
+
+	return worker.NewWorker(cfg, store).Run(signalCtx)
+}
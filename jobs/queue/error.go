@@ -0,0 +1,17 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrNoJob         = errors.New("there is no job with this id")
+	ErrJobID         = errors.New("job id cannot contain path separators")
+	ErrJobFinished   = errors.New("job has been already finished")
+	ErrWorkerCrashed = errors.New("worker of job has crashed")
+)
+
+func ErrWrapJob(id string, err error) error {
+	return fmt.Errorf("job - %q: %w", id, err)
+}
@@ -0,0 +1,54 @@
+package queue
+
+import (
+	"time"
+)
+
+type Status string
+
+const (
+	StatusQueued   Status = "queued"
+	StatusRunning  Status = "running"
+	StatusDone     Status = "done"
+	StatusFailed   Status = "failed"
+	StatusCanceled Status = "canceled"
+	// StatusRegressed is final status of run which has found regression, it isn't retried
+	StatusRegressed Status = "regressed"
+)
+
+type Job struct {
+	ID          string     `json:"id"`
+	Tool        string     `json:"tool"`
+	Args        []string   `json:"args"`
+	Status      Status     `json:"status"`
+	Attempts    int        `json:"attempts"`
+	MaxAttempts int        `json:"max_attempts"`
+	CreatedAt   time.Time  `json:"created_at"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	LogPath     string     `json:"log_path"`
+}
+
+func (j *Job) IsFinished() bool {
+	return j.Status == StatusDone || j.Status == StatusFailed || j.Status == StatusCanceled ||
+		j.Status == StatusRegressed
+}
+
+// Finish sets final status, failed job is queued again until attempts are over
+func (j *Job) Finish(status Status, err error) {
+	j.Error = ""
+
+	if err != nil {
+		j.Error = err.Error()
+	}
+
+	if status == StatusFailed && j.Attempts < j.MaxAttempts {
+		j.Status = StatusQueued
+		return
+	}
+
+	finishedAt := time.Now()
+	j.Status = status
+	j.FinishedAt = &finishedAt
+}
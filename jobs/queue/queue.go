@@ -0,0 +1,276 @@
+package queue
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"wbx-script/searchType/atomicfile"
+)
+
+const (
+	jobFileSuffix    = ".json"
+	lockFileSuffix   = ".lock"
+	cancelFileSuffix = ".cancel"
+	logFileSuffix    = ".log"
+)
+
+const (
+	idTimeLayout = "20060102T150405"
+	idRandBytes  = 4
+)
+
+// Store keeps every job in its own json file, running job is locked by flock of lock file which is held open by worker
+type Store struct {
+	dir   string
+	mu    *sync.Mutex
+	locks map[string]*os.File
+}
+
+func NewStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	return &Store{dir: dir, mu: &sync.Mutex{}, locks: make(map[string]*os.File)}, nil
+}
+
+func (s *Store) path(id, suffix string) string {
+	return filepath.Join(s.dir, id+suffix)
+}
+
+func newID() (string, error) {
+	random := make([]byte, idRandBytes)
+
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+
+	return time.Now().UTC().Format(idTimeLayout) + "-" + hex.EncodeToString(random), nil
+}
+
+func (s *Store) Submit(tool string, args []string, maxAttempts int) (Job, error) {
+	id, err := newID()
+
+	if err != nil {
+		return Job{}, err
+	}
+
+	job := Job{
+		ID:          id,
+		Tool:        tool,
+		Args:        args,
+		Status:      StatusQueued,
+		MaxAttempts: maxAttempts,
+		CreatedAt:   time.Now(),
+		LogPath:     s.path(id, logFileSuffix),
+	}
+
+	return job, s.Save(job)
+}
+
+func (s *Store) Save(job Job) error {
+	data, err := json.MarshalIndent(job, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	return atomicfile.WriteFile(s.path(job.ID, jobFileSuffix), data)
+}
+
+func (s *Store) Get(id string) (Job, error) {
+	job := Job{}
+
+	if filepath.Base(id) != id || strings.HasPrefix(id, ".") {
+		return job, ErrWrapJob(id, ErrJobID)
+	}
+
+	data, err := os.ReadFile(s.path(id, jobFileSuffix))
+
+	if errors.Is(err, os.ErrNotExist) {
+		return job, ErrWrapJob(id, ErrNoJob)
+	}
+
+	if err != nil {
+		return job, err
+	}
+
+	return job, json.Unmarshal(data, &job)
+}
+
+// List returns jobs in order of submitting
+func (s *Store) List() ([]Job, error) {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*"+jobFileSuffix))
+
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make([]Job, 0, len(paths))
+
+	for _, path := range paths {
+		job, errGet := s.Get(strings.TrimSuffix(filepath.Base(path), jobFileSuffix))
+
+		if errGet != nil {
+			return nil, errGet
+		}
+
+		jobs = append(jobs, job)
+	}
+
+	slices.SortFunc(jobs, func(a, b Job) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+
+	return jobs, nil
+}
+
+// Claim locks job for current process till Release, lock of crashed process is released by system,
+// so there is no takeover of stale locks which could be raced by other workers
+func (s *Store) Claim(id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, held := s.locks[id]; held {
+		return false, nil
+	}
+
+	file, err := os.OpenFile(s.path(id, lockFileSuffix), os.O_RDWR|os.O_CREATE, 0o644)
+
+	if err != nil {
+		return false, err
+	}
+
+	if err = syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return false, file.Close()
+		}
+
+		return false, errors.Join(err, file.Close())
+	}
+
+	// pid is written only for debugging, ownership is given by flock
+	if err = file.Truncate(0); err == nil {
+		_, err = file.WriteString(strconv.Itoa(os.Getpid()))
+	}
+
+	if err != nil {
+		return false, errors.Join(err, file.Close())
+	}
+
+	s.locks[id] = file
+
+	return true, nil
+}
+
+// Release unlocks job by closing lock file, the file isn't removed,
+// otherwise another worker could lock new file while old one is still locked
+func (s *Store) Release(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var err error
+
+	if file, held := s.locks[id]; held {
+		delete(s.locks, id)
+		err = file.Close()
+	}
+
+	return errors.Join(err, removeIfExists(s.path(id, cancelFileSuffix)))
+}
+
+func removeIfExists(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// Cancel finishes queued job at once, running job is stopped by its worker
+func (s *Store) Cancel(id string) (Job, error) {
+	job, err := s.Get(id)
+
+	if err != nil {
+		return job, err
+	}
+
+	if job.IsFinished() {
+		return job, ErrWrapJob(id, ErrJobFinished)
+	}
+
+	claimed, err := s.Claim(id)
+
+	if err != nil {
+		return job, err
+	}
+
+	if !claimed {
+		return job, atomicfile.WriteFile(s.path(id, cancelFileSuffix), nil)
+	}
+
+	// job could be finished by worker before claiming
+	if job, err = s.Get(id); err == nil && !job.IsFinished() {
+		job.Finish(StatusCanceled, nil)
+		err = s.Save(job)
+	}
+
+	return job, errors.Join(err, s.Release(id))
+}
+
+func (s *Store) IsCancelRequested(id string) bool {
+	_, err := os.Stat(s.path(id, cancelFileSuffix))
+
+	return err == nil
+}
+
+// Recover queues again jobs which were running by crashed worker
+func (s *Store) Recover() ([]Job, error) {
+	jobs, err := s.List()
+
+	if err != nil {
+		return nil, err
+	}
+
+	recovered := make([]Job, 0)
+
+	for _, job := range jobs {
+		if job.Status != StatusRunning {
+			continue
+		}
+
+		claimed, errClaim := s.Claim(job.ID)
+
+		if errClaim != nil {
+			return recovered, errClaim
+		}
+
+		if !claimed {
+			continue
+		}
+
+		if s.IsCancelRequested(job.ID) {
+			job.Finish(StatusCanceled, nil)
+		} else {
+			job.Finish(StatusFailed, ErrWrapJob(job.ID, ErrWorkerCrashed))
+		}
+
+		if err = errors.Join(s.Save(job), s.Release(job.ID)); err != nil {
+			return recovered, err
+		}
+
+		recovered = append(recovered, job)
+	}
+
+	return recovered, nil
+}
@@ -0,0 +1,7 @@
+package worker
+
+import (
+	"errors"
+)
+
+var ErrInterrupted = errors.New("job has been interrupted by worker stopping")
@@ -0,0 +1,224 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+
+	"wbx-script/jobs/config"
+	"wbx-script/jobs/queue"
+	"wbx-script/searchType/logger"
+)
+
+// terminateTimeout is time for tool to finish gracefully after SIGTERM
+const terminateTimeout = 30 * time.Second
+
+// regressionExitCode is exit code of tool which has found regression, e.g. violated thresholds of comparison
+// in queryVisualizer, the next attempt gives the same result, so such job isn't retried
+const regressionExitCode = 2
+
+type Worker struct {
+	cfg     *config.Config
+	store   *queue.Store
+	slots   chan struct{}
+	running *sync.WaitGroup
+}
+
+func NewWorker(cfg *config.Config, store *queue.Store) *Worker {
+	return &Worker{
+		cfg:     cfg,
+		store:   store,
+		slots:   make(chan struct{}, cfg.Concurrency),
+		running: &sync.WaitGroup{},
+	}
+}
+
+func (w *Worker) Run(ctx context.Context) error {
+	defer func() {
+		w.running.Wait()
+		logger.Info("Worker has finished")
+	}()
+
+	recovered, err := w.store.Recover()
+
+	for _, job := range recovered {
+		logger.Info(fmt.Sprintf("Job %q of crashed worker has been recovered with status %q", job.ID, job.Status))
+	}
+
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(w.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err = w.schedule(ctx); err != nil {
+			return err
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// schedule starts queued jobs while there are free slots
+func (w *Worker) schedule(ctx context.Context) error {
+	jobs, err := w.store.List()
+
+	if err != nil {
+		return err
+	}
+
+	for _, job := range jobs {
+		if len(w.slots) == cap(w.slots) || ctx.Err() != nil {
+			return nil
+		}
+
+		if job.Status != queue.StatusQueued {
+			continue
+		}
+
+		claimed, errClaim := w.store.Claim(job.ID)
+
+		if errClaim != nil {
+			return errClaim
+		}
+
+		if !claimed {
+			continue
+		}
+
+		id := job.ID
+
+		// job could be changed before claiming
+		if job, err = w.store.Get(id); err != nil || job.Status != queue.StatusQueued {
+			if err = errors.Join(err, w.store.Release(id)); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		w.slots <- struct{}{}
+		w.running.Add(1)
+
+		go func() {
+			defer func() {
+				<-w.slots
+				w.running.Done()
+			}()
+
+			if errRun := w.run(ctx, job); errRun != nil {
+				logger.Error(errRun.Error())
+			}
+		}()
+	}
+
+	return nil
+}
+
+func (w *Worker) run(ctx context.Context, job queue.Job) (err error) {
+	defer func() {
+		err = errors.Join(err, w.store.Release(job.ID))
+	}()
+
+	startedAt := time.Now()
+	job.Status = queue.StatusRunning
+	job.StartedAt = &startedAt
+	job.Attempts++
+
+	if err = w.store.Save(job); err != nil {
+		return err
+	}
+
+	logger.Info(fmt.Sprintf("Job %q has started, attempt %d of %d", job.ID, job.Attempts, job.MaxAttempts))
+
+	status, errJob := w.execute(ctx, job)
+
+	if errors.Is(errJob, ErrInterrupted) {
+		// interrupted attempt isn't counted, job is continued by next worker
+		job.Attempts--
+		job.Status = queue.StatusQueued
+	} else {
+		job.Finish(status, errJob)
+	}
+
+	logger.Info(fmt.Sprintf("Job %q has finished with status %q", job.ID, job.Status))
+
+	return w.store.Save(job)
+}
+
+func (w *Worker) execute(ctx context.Context, job queue.Job) (queue.Status, error) {
+	logFile, err := os.OpenFile(job.LogPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+
+	if err != nil {
+		return queue.StatusFailed, err
+	}
+
+	defer logFile.Close()
+
+	cmd := exec.Command(w.cfg.ToolBinaries[job.Tool], job.Args...)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+
+	if err = cmd.Start(); err != nil {
+		return queue.StatusFailed, err
+	}
+
+	exited := make(chan error, 1)
+
+	go func() {
+		exited <- cmd.Wait()
+	}()
+
+	ticker := time.NewTicker(w.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case err = <-exited:
+			var exitErr *exec.ExitError
+
+			if errors.As(err, &exitErr) && exitErr.ExitCode() == regressionExitCode {
+				return queue.StatusRegressed, err
+			}
+
+			if err != nil {
+				return queue.StatusFailed, err
+			}
+
+			return queue.StatusDone, nil
+		case <-ticker.C:
+			if w.store.IsCancelRequested(job.ID) {
+				return queue.StatusCanceled, terminate(cmd, exited)
+			}
+		case <-ctx.Done():
+			return queue.StatusQueued, errors.Join(ErrInterrupted, terminate(cmd, exited))
+		}
+	}
+}
+
+func terminate(cmd *exec.Cmd, exited <-chan error) error {
+	if err := cmd.Process.Signal(syscall.SIGTERM); err != nil {
+		return err
+	}
+
+	timer := time.NewTimer(terminateTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-exited:
+		return nil
+	case <-timer.C:
+		return cmd.Process.Kill()
+	}
+}
@@ -0,0 +1,284 @@
+package checker
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"wbx-script/presetsChecker/config"
+	"wbx-script/searchType/atomicfile"
+	"wbx-script/searchType/balancer"
+	"wbx-script/searchType/logger"
+	"wbx-script/searchType/presets"
+	"wbx-script/searchType/saver"
+)
+
+const (
+	statusMissing = "missing"
+	statusEmpty   = "empty"
+	// statusFailed presets aren't written to report, they are only counted and logged
+	statusFailed = "failed"
+)
+
+const (
+	comma            = ","
+	newline          = "\n"
+	queriesSeparator = " | "
+)
+
+type presetUsage struct {
+	categories map[string]struct{}
+	queries    map[string]struct{}
+}
+
+type Checker struct {
+	cfg    *config.Config
+	prober *presets.Prober
+	usages map[string]*presetUsage
+	counts map[string]int
+}
+
+func NewChecker(cfg *config.Config) *Checker {
+	return &Checker{
+		cfg:    cfg,
+		prober: presets.NewProber(
+			balancer.New(cfg.BucketServerURLs, cfg.Balancer),
+			cfg.Rps,
+			cfg.CountOfRetry,
+			cfg.GoErrGroupLimiter,
+			cfg.Timeout,
+		),
+		usages: make(map[string]*presetUsage),
+		counts: make(map[string]int),
+	}
+}
+
+func (c *Checker) usage(preset string) *presetUsage {
+	usage, ok := c.usages[preset]
+
+	if !ok {
+		usage = &presetUsage{
+			categories: make(map[string]struct{}),
+			queries:    make(map[string]struct{}),
+		}
+		c.usages[preset] = usage
+	}
+
+	return usage
+}
+
+func (c *Checker) Run(ctx context.Context) error {
+	defer c.prober.Stop()
+
+	if err := c.load(); err != nil {
+		return err
+	}
+
+	presetIDs := sortedKeys(c.usages)
+
+	logger.Info("Checking " + strconv.Itoa(len(presetIDs)) + " presets on bucket server")
+
+	results, err := c.prober.ProbeAll(ctx, presetIDs)
+
+	if err != nil {
+		return err
+	}
+
+	if err = c.writeReport(results); err != nil {
+		return err
+	}
+
+	logger.Info(fmt.Sprintf(
+		"Presets have been checked: %d missing, %d empty, %d failed of %d",
+		c.counts[statusMissing], c.counts[statusEmpty], c.counts[statusFailed], len(results),
+	))
+
+	return nil
+}
+
+func (c *Checker) load() error {
+	if c.cfg.ResultsPath != "" {
+		if err := c.loadResults(); err != nil {
+			return err
+		}
+	}
+
+	if c.cfg.ClassificationsPath != "" {
+		if err := c.loadClassifications(c.cfg.ClassificationsPath); err != nil {
+			return err
+		}
+	}
+
+	if len(c.usages) == 0 {
+		return ErrNoPresets
+	}
+
+	return nil
+}
+
+// loadResults reads presets and affected queries of every category folder of searchType results
+func (c *Checker) loadResults() error {
+	entries, err := os.ReadDir(c.cfg.ResultsPath)
+
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		directory := filepath.Join(c.cfg.ResultsPath, entry.Name())
+
+		if !entry.IsDir() {
+			continue
+		}
+
+		content, errRead := os.ReadFile(filepath.Join(directory, saver.PresetsFileName))
+
+		if errors.Is(errRead, os.ErrNotExist) {
+			continue
+		}
+
+		if errRead != nil {
+			return errRead
+		}
+
+		for _, preset := range strings.Split(string(content), c.cfg.PresetsSeparator) {
+			if preset = strings.TrimSpace(preset); preset != "" {
+				c.usage(preset).categories[entry.Name()] = struct{}{}
+			}
+		}
+
+		if err = c.loadQueries(filepath.Join(directory, saver.QueriesFileName)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Checker) loadQueries(path string) (err error) {
+	file, err := os.Open(path)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = errors.Join(err, file.Close())
+	}()
+
+	scanner := bufio.NewScanner(file)
+
+	// first line is header
+	for number := 1; scanner.Scan(); number++ {
+		if number == 1 {
+			continue
+		}
+
+		text, catalogValue, ok := strings.Cut(scanner.Text(), c.cfg.CsvSeparator)
+
+		if !ok {
+			return ErrWrapQueriesLine(path, number)
+		}
+
+		presetIDs, errParse := presets.FromCatalogValue(catalogValue)
+
+		if errParse != nil {
+			return errors.Join(ErrWrapQueriesLine(path, number), errParse)
+		}
+
+		for _, preset := range presetIDs {
+			if usage, known := c.usages[preset]; known {
+				usage.queries[text] = struct{}{}
+			}
+		}
+	}
+
+	return scanner.Err()
+}
+
+func (c *Checker) status(result presets.ProbeResult) string {
+	switch {
+	case result.Err != nil:
+		c.counts[statusFailed]++
+		logger.Error(fmt.Sprintf("Preset %q hasn't been checked: %s", result.Preset, result.Err))
+
+		return ""
+	case !result.Exists:
+		c.counts[statusMissing]++
+		return statusMissing
+	case result.Products == 0:
+		c.counts[statusEmpty]++
+		return statusEmpty
+	default:
+		return ""
+	}
+}
+
+func (c *Checker) writeReport(results []presets.ProbeResult) (err error) {
+	file, err := atomicfile.Create(c.cfg.ReportPath)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = errors.Join(file.Close(), err)
+	}()
+
+	sep := c.cfg.CsvSeparator
+	writer := bufio.NewWriter(file)
+
+	if _, err = writer.WriteString(
+		"preset" + sep + "status" + sep + "categories" + sep + "queries_count" + sep + "queries" + newline,
+	); err != nil {
+		return err
+	}
+
+	for _, result := range results {
+		status := c.status(result)
+
+		if status == "" {
+			continue
+		}
+
+		usage := c.usages[result.Preset]
+
+		if _, err = writer.WriteString(result.Preset +
+			sep + status +
+			sep + strings.Join(sortedKeys(usage.categories), comma) +
+			sep + strconv.Itoa(len(usage.queries)) +
+			sep + strings.Join(sortedKeys(usage.queries), queriesSeparator) +
+			newline,
+		); err != nil {
+			return err
+		}
+	}
+
+	if err = writer.Flush(); err != nil {
+		return err
+	}
+
+	return file.Commit()
+}
+
+func sortedKeys[V any](set map[string]V) []string {
+	keys := make([]string, 0, len(set))
+
+	for key := range set {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	return keys
+}
@@ -0,0 +1,67 @@
+package checker
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+)
+
+// classification is result of /classify of searchType serve mode, only used fields are decoded
+type classification struct {
+	Query    string   `json:"query"`
+	Category string   `json:"category"`
+	Presets  []string `json:"presets"`
+	Error    string   `json:"error"`
+}
+
+// classifyResponse is single or batch response of /classify
+type classifyResponse struct {
+	classification
+	Results []classification `json:"results"`
+}
+
+// loadClassifications reads presets and queries of saved responses of searchType serve mode,
+// responses are JSON values which follow each other, e.g. one per line
+func (c *Checker) loadClassifications(path string) (err error) {
+	file, err := os.Open(path)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = errors.Join(err, file.Close())
+	}()
+
+	decoder := json.NewDecoder(file)
+
+	for number := 1; ; number++ {
+		response := classifyResponse{}
+
+		if err = decoder.Decode(&response); errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return errors.Join(ErrWrapClassification(path, number), err)
+		}
+
+		if response.Results == nil {
+			response.Results = []classification{response.classification}
+		}
+
+		for _, result := range response.Results {
+			// failed classifications have neither category nor presets
+			if result.Error != "" {
+				continue
+			}
+
+			for _, preset := range result.Presets {
+				usage := c.usage(preset)
+				usage.categories[result.Category] = struct{}{}
+				usage.queries[result.Query] = struct{}{}
+			}
+		}
+	}
+}
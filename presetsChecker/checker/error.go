@@ -0,0 +1,20 @@
+package checker
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrNoPresets      = errors.New("there are no presets in list.presets files of results path or in classifications")
+	ErrQueriesLine    = errors.New("line of queries file should contain text and query")
+	ErrClassification = errors.New("classification should be JSON response of searchType serve mode")
+)
+
+func ErrWrapQueriesLine(path string, number int) error {
+	return fmt.Errorf("file - %q, line - %d: %w", path, number, ErrQueriesLine)
+}
+
+func ErrWrapClassification(path string, number int) error {
+	return fmt.Errorf("file - %q, response - %d: %w", path, number, ErrClassification)
+}
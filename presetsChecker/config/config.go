@@ -0,0 +1,196 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"wbx-script/searchType/balancer"
+)
+
+const (
+	defaultTimeout          = 3 * time.Second
+	defaultLogPeriod        = 3 * time.Second
+	defaultRetry            = 0
+	defaultRps              = 1
+	defaultCsvSeparator     = "\t"
+	defaultPresetsSeparator = ","
+	defaultReportFileName   = "dead_presets.csv"
+)
+
+type Config struct {
+	ResultsPath string
+	// ClassificationsPath is file of JSON output of searchType serve mode, it's used with or instead of ResultsPath
+	ClassificationsPath string
+	ReportPath          string
+	BucketServerURLs    []*url.URL
+	Balancer            balancer.Config
+	PresetsSeparator    string
+	CsvSeparator        string
+	Rps                 int
+	CountOfRetry        int
+	Timeout             time.Duration
+	LogPeriod           time.Duration
+	GoErrGroupLimiter   int
+}
+
+func parseEnvironment() (int, error) {
+	limit := os.Getenv("GO_ERR_GROUP_LIMIT")
+
+	if limit == "" {
+		limit = "100"
+	}
+
+	var (
+		err   error
+		value int
+	)
+
+	if value, err = strconv.Atoi(limit); err != nil || value <= 0 {
+		return 0, ErrInvalidateGroupLimit
+	}
+
+	return value, nil
+}
+
+func Parse() (*Config, error) {
+	cfg := Config{}
+
+	var bucketServerURLStr string
+
+	flag.StringVar(
+		&cfg.ResultsPath,
+		"results-path",
+		"",
+		"Path to searchType results with category folders containing list.presets and queries.csv",
+	)
+	flag.StringVar(
+		&cfg.ClassificationsPath,
+		"classifications-path",
+		"",
+		"Path to JSON output of searchType serve mode: single or batch responses of /classify, one per line",
+	)
+	flag.StringVar(
+		&cfg.ReportPath,
+		"report-path",
+		"",
+		"Path of report file, "+defaultReportFileName+" in results path or next to classifications file by default",
+	)
+	flag.StringVar(&bucketServerURLStr, "bucket-server-url", "", "Comma separated urls of bucket server replicas")
+	flag.StringVar(
+		&cfg.Balancer.Strategy,
+		"balance",
+		balancer.StrategyRoundRobin,
+		"Balancing of requests over bucket server replicas: round-robin|least-inflight",
+	)
+	flag.IntVar(&cfg.Balancer.MaxFails, "max-fails", balancer.DefaultMaxFails, "Consecutive failures after which replica is ejected")
+	flag.DurationVar(&cfg.Balancer.EjectFor, "eject-for", balancer.DefaultEjectFor, "Time of replica ejection(duration format)")
+	flag.IntVar(&cfg.Balancer.Failover, "failover", balancer.DefaultFailover, "Number of other replicas tried after failed request")
+	flag.StringVar(&cfg.PresetsSeparator, "presets-separator", defaultPresetsSeparator, "Separator of presets in list.presets files")
+	flag.StringVar(&cfg.CsvSeparator, "csv-separator", defaultCsvSeparator, "Separator of queries.csv files and report")
+	flag.IntVar(&cfg.Rps, "rps", defaultRps, "Request per second")
+	flag.IntVar(&cfg.CountOfRetry, "retry", defaultRetry, "Count of retry per request to bucket server")
+	flag.DurationVar(&cfg.Timeout, "timeout", defaultTimeout, "Timeout of making request to server(duration format)")
+	flag.DurationVar(&cfg.LogPeriod, "logger-period", defaultLogPeriod, "Period of logger's printing(duration format)")
+	flag.Parse()
+
+	if cfg.ReportPath == "" {
+		directory := cfg.ResultsPath
+
+		if directory == "" {
+			directory = filepath.Dir(cfg.ClassificationsPath)
+		}
+
+		cfg.ReportPath = filepath.Join(directory, defaultReportFileName)
+	}
+
+	if err := cfg.validate(bucketServerURLStr); err != nil {
+		return nil, err
+	}
+
+	var err error
+
+	if cfg.GoErrGroupLimiter, err = parseEnvironment(); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+func checkURL(urlStr string) (*url.URL, error) {
+	u, err := url.Parse(urlStr)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme == "" {
+		return u, ErrSchema
+	}
+
+	if u.Host == "" {
+		return u, ErrHost
+	}
+
+	return u, nil
+}
+
+func (c *Config) validate(bucketURLStr string) error {
+	var err, errSum error
+
+	if c.ResultsPath == "" && c.ClassificationsPath == "" {
+		errSum = errors.Join(errSum, ErrNoInput)
+	}
+
+	if c.ResultsPath != "" {
+		if info, errStat := os.Stat(c.ResultsPath); errStat != nil || !info.IsDir() {
+			errSum = errors.Join(errSum, errStat, ErrResultsPath)
+		}
+	}
+
+	if c.ClassificationsPath != "" {
+		if info, errStat := os.Stat(c.ClassificationsPath); errStat != nil || info.IsDir() {
+			errSum = errors.Join(errSum, errStat, ErrClassificationsPath)
+		}
+	}
+
+	if len(balancer.SplitEndpoints(bucketURLStr)) == 0 {
+		errSum = errors.Join(errSum, ErrSchema)
+	}
+
+	for _, endpoint := range balancer.SplitEndpoints(bucketURLStr) {
+		u, errURL := checkURL(endpoint)
+		errSum = errors.Join(errSum, errURL)
+		c.BucketServerURLs = append(c.BucketServerURLs, u)
+	}
+
+	if err = c.Balancer.Validate(); err != nil {
+		errSum = errors.Join(errSum, err)
+	}
+
+	if c.PresetsSeparator == "" || c.CsvSeparator == "" {
+		errSum = errors.Join(errSum, ErrSeparator)
+	}
+
+	if c.Rps <= 0 {
+		errSum = errors.Join(errSum, ErrRps)
+	}
+
+	if c.Timeout <= 0 {
+		errSum = errors.Join(errSum, ErrTimeout)
+	}
+
+	if c.CountOfRetry < 0 {
+		errSum = errors.Join(errSum, ErrRetry)
+	}
+
+	if c.LogPeriod <= 0 {
+		errSum = errors.Join(errSum, ErrLoggerPeriod)
+	}
+
+	return errSum
+}
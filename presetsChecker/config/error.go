@@ -0,0 +1,19 @@
+package config
+
+import (
+	"errors"
+)
+
+var (
+	ErrSchema               = errors.New("please setup schema for url")
+	ErrHost                 = errors.New("please setup host for url")
+	ErrResultsPath          = errors.New("results path should be existing directory with searchType results")
+	ErrClassificationsPath  = errors.New("classifications path should be existing file with JSON output of searchType")
+	ErrNoInput              = errors.New("results path or classifications path should be set")
+	ErrLoggerPeriod         = errors.New("logger period cannot be less than 1 milli-second")
+	ErrTimeout              = errors.New("timeout per request cannot be less than zero or equal")
+	ErrRetry                = errors.New("retry per request cannot be less than zero")
+	ErrRps                  = errors.New("request per second cannot be less than zero or equal")
+	ErrSeparator            = errors.New("separators cannot be empty")
+	ErrInvalidateGroupLimit = errors.New("validation hasn't been passed for 'GO_ERR_GROUP_LIMIT' env, cannot be less or equal zero")
+)
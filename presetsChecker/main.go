@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"wbx-script/presetsChecker/checker"
+	"wbx-script/presetsChecker/config"
+	"wbx-script/searchType/logger"
+)
+
+// failureExitCode lets jobs worker and CI distinguish failed check from complete one
+const failureExitCode = 1
+
+func main() {
+	os.Exit(run())
+}
+
+func run() int {
+	cfg, err := config.Parse()
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	logger.GlobalLogger = logger.NewLogger(
+		slog.New(slog.NewTextHandler(os.Stdout, nil)),
+		cfg.LogPeriod,
+	)
+
+	logger.GlobalLogger.Run()
+
+	defer logger.GlobalLogger.Stop()
+
+	signalCtx, signalStop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+
+	defer signalStop()
+
+	if err = checker.NewChecker(cfg).Run(signalCtx); err != nil {
+		logger.Error(err.Error())
+		return failureExitCode
+	}
+
+	return 0
+}
@@ -0,0 +1,298 @@
+package compare
+
+import (
+	"encoding/csv"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"wbx-script/queryVisualizer/executor"
+	"wbx-script/searchType/atomicfile"
+	"wbx-script/searchType/saver"
+)
+
+const comparisonFileSuffix = "_comparison.tsv"
+
+const comma = ","
+
+const (
+	StatusSame    = "same"
+	StatusChanged = "changed"
+	// StatusNew is set to query without cards in previous version
+	StatusNew = "new"
+	// StatusEmpty is set to query without cards in current version, including query missing from it
+	StatusEmpty = "empty"
+)
+
+type Row struct {
+	Slug             string
+	Text             string
+	Status           string
+	PreviousCards    int
+	CurrentCards     int
+	OverlapAtK       float64
+	PreviousCategory string
+	CurrentCategory  string
+}
+
+// CategoryShifted is true if query part of the same text has got another category
+func (r *Row) CategoryShifted() bool {
+	return r.PreviousCategory != "" && r.CurrentCategory != "" && r.PreviousCategory != r.CurrentCategory
+}
+
+type indexEntry struct {
+	text  string
+	query string
+}
+
+type Report struct {
+	Previous string
+	Current  string
+	K        int
+	Rows     []Row
+}
+
+// ComparisonFilePath returns path of comparison of version with previous one
+func ComparisonFilePath(resultsPath, versionName string) string {
+	return filepath.Join(executor.ResultsDirectory(resultsPath), versionName+comparisonFileSuffix)
+}
+
+// Compare matches cards files of queries of both versions, query of only one version is compared with no cards
+func Compare(resultsPath, previous, current string, k int) (*Report, error) {
+	entries, err := readIndex(resultsPath, current)
+
+	if err != nil {
+		return nil, err
+	}
+
+	previousEntries, err := readIndex(resultsPath, previous)
+
+	if err != nil {
+		return nil, err
+	}
+
+	slugs := make([]string, 0, len(entries)+len(previousEntries))
+
+	for slug := range entries {
+		slugs = append(slugs, slug)
+	}
+
+	for slug := range previousEntries {
+		if _, ok := entries[slug]; !ok {
+			slugs = append(slugs, slug)
+		}
+	}
+
+	slices.Sort(slugs)
+
+	report := &Report{
+		Previous: previous,
+		Current:  current,
+		K:        k,
+		Rows:     make([]Row, 0, len(slugs)),
+	}
+
+	for _, slug := range slugs {
+		entry, previousEntry := entries[slug], previousEntries[slug]
+		previousCards, err := readCards(executor.CardsFilePath(resultsPath, slug, previous))
+
+		if err != nil {
+			return nil, err
+		}
+
+		currentCards, err := readCards(executor.CardsFilePath(resultsPath, slug, current))
+
+		if err != nil {
+			return nil, err
+		}
+
+		text := entry.text
+
+		if text == "" {
+			text = previousEntry.text
+		}
+
+		row := newRow(slug, text, previousCards, currentCards, k)
+
+		if row.CurrentCategory, err = categoryOf(entry.query); err != nil {
+			return nil, err
+		}
+
+		if row.PreviousCategory, err = categoryOf(previousEntry.query); err != nil {
+			return nil, err
+		}
+
+		report.Rows = append(report.Rows, row)
+	}
+
+	return report, nil
+}
+
+func newRow(slug, text string, previousCards, currentCards []string, k int) Row {
+	row := Row{
+		Slug:          slug,
+		Text:          text,
+		PreviousCards: len(previousCards),
+		CurrentCards:  len(currentCards),
+	}
+
+	switch {
+	case len(currentCards) == 0:
+		row.Status = StatusEmpty
+	case len(previousCards) == 0:
+		row.Status = StatusNew
+	case slices.Equal(previousCards, currentCards):
+		row.Status = StatusSame
+	default:
+		row.Status = StatusChanged
+	}
+
+	if len(previousCards) > 0 && len(currentCards) > 0 {
+		row.OverlapAtK = overlapAtK(previousCards, currentCards, k)
+	}
+
+	return row
+}
+
+// overlapAtK is share of common cards among first k cards of both versions
+func overlapAtK(previousCards, currentCards []string, k int) float64 {
+	previousTop := previousCards[:min(k, len(previousCards))]
+	currentTop := currentCards[:min(k, len(currentCards))]
+
+	common := 0
+
+	for _, card := range currentTop {
+		if slices.Contains(previousTop, card) {
+			common++
+		}
+	}
+
+	return float64(common) / float64(max(len(previousTop), len(currentTop)))
+}
+
+func categoryOf(query string) (string, error) {
+	if query == "" {
+		return "", nil
+	}
+
+	return saver.CategoryOf(query)
+}
+
+// readIndex takes query folders from index of version, cards files are used for versions without index.
+// Version without both index and cards files doesn't exist, e.g. its name is mistyped
+func readIndex(resultsPath, versionName string) (entries map[string]indexEntry, err error) {
+	entries = make(map[string]indexEntry)
+	file, err := os.Open(executor.IndexFilePath(resultsPath, versionName))
+
+	if errors.Is(err, os.ErrNotExist) {
+		cardsFiles, errGlob := filepath.Glob(executor.CardsFilePath(resultsPath, "*", versionName))
+
+		if errGlob == nil && len(cardsFiles) == 0 {
+			return nil, ErrWrapNoVersion(versionName)
+		}
+
+		for _, cardsFile := range cardsFiles {
+			entries[filepath.Base(filepath.Dir(cardsFile))] = indexEntry{}
+		}
+
+		return entries, errGlob
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		err = errors.Join(err, file.Close())
+	}()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+
+	records, err := reader.ReadAll()
+
+	if err != nil {
+		return nil, err
+	}
+
+	// first record is header: slug, id, text, query
+	for _, record := range records[min(1, len(records)):] {
+		if len(record) > 3 {
+			entries[record[0]] = indexEntry{text: record[2], query: record[3]}
+		}
+	}
+
+	return entries, nil
+}
+
+func readCards(path string) ([]string, error) {
+	content, err := os.ReadFile(path)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil || len(content) == 0 {
+		return nil, err
+	}
+
+	return strings.Split(string(content), comma), nil
+}
+
+func (r *Report) Counts() map[string]int {
+	counts := make(map[string]int)
+
+	for _, row := range r.Rows {
+		counts[row.Status]++
+	}
+
+	return counts
+}
+
+func (r *Report) Write(path string) (err error) {
+	file, err := atomicfile.Create(path)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = errors.Join(err, file.Close())
+	}()
+
+	writer := csv.NewWriter(file)
+	writer.Comma = '\t'
+
+	if err = writer.Write([]string{
+		"slug", "text", "status", r.Previous + "_cards", r.Current + "_cards", "overlap_at_" + strconv.Itoa(r.K),
+		r.Previous + "_category", r.Current + "_category",
+	}); err != nil {
+		return err
+	}
+
+	for _, row := range r.Rows {
+		if err = writer.Write([]string{
+			row.Slug,
+			row.Text,
+			row.Status,
+			strconv.Itoa(row.PreviousCards),
+			strconv.Itoa(row.CurrentCards),
+			strconv.FormatFloat(row.OverlapAtK, 'f', 4, 64),
+			row.PreviousCategory,
+			row.CurrentCategory,
+		}); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	if err = writer.Error(); err != nil {
+		return err
+	}
+
+	return file.Commit()
+}
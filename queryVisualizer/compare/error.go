@@ -0,0 +1,18 @@
+package compare
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrRegression = errors.New("thresholds of comparison have been violated")
+
+func ErrWrapRegression(violations int, path string) error {
+	return fmt.Errorf("%d violations, see %q: %w", violations, path, ErrRegression)
+}
+
+var ErrNoVersion = errors.New("there is neither index nor cards files of results version")
+
+func ErrWrapNoVersion(versionName string) error {
+	return fmt.Errorf("version - %q: %w", versionName, ErrNoVersion)
+}
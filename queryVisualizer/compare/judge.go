@@ -0,0 +1,126 @@
+package compare
+
+import (
+	"encoding/csv"
+	"errors"
+	"path/filepath"
+	"strconv"
+
+	"wbx-script/queryVisualizer/config"
+	"wbx-script/queryVisualizer/executor"
+	"wbx-script/searchType/atomicfile"
+)
+
+const regressionsFileSuffix = "_regressions.tsv"
+
+const (
+	reasonLowOverlap    = "low_overlap"
+	reasonEmpty         = "empty"
+	reasonCategoryShift = "category_shift"
+)
+
+type Violation struct {
+	Slug   string
+	Text   string
+	Reason string
+	Value  string
+}
+
+// RegressionsFilePath returns path of summary with queries which violate thresholds
+func RegressionsFilePath(resultsPath, versionName string) string {
+	return filepath.Join(executor.ResultsDirectory(resultsPath), versionName+regressionsFileSuffix)
+}
+
+// Judge checks report by thresholds, count thresholds are violated by all counted queries together
+func (r *Report) Judge(cfg *config.CompareConfig) []Violation {
+	var lowOverlap, empty, shifted []Violation
+
+	for _, row := range r.Rows {
+		if cfg.MinOverlap >= 0 && row.PreviousCards > 0 && row.CurrentCards > 0 && row.OverlapAtK < cfg.MinOverlap {
+			lowOverlap = append(lowOverlap, Violation{
+				row.Slug, row.Text, reasonLowOverlap, strconv.FormatFloat(row.OverlapAtK, 'f', 4, 64),
+			})
+		}
+
+		if row.Status == StatusEmpty {
+			empty = append(empty, Violation{row.Slug, row.Text, reasonEmpty, strconv.Itoa(row.PreviousCards)})
+		}
+
+		if row.CategoryShifted() {
+			shifted = append(shifted, Violation{
+				row.Slug, row.Text, reasonCategoryShift, row.PreviousCategory + "->" + row.CurrentCategory,
+			})
+		}
+	}
+
+	violations := lowOverlap
+
+	if cfg.MaxEmpty >= 0 && len(empty) > cfg.MaxEmpty {
+		violations = append(violations, empty...)
+	}
+
+	if cfg.MaxCategoryShifts >= 0 && len(shifted) > cfg.MaxCategoryShifts {
+		violations = append(violations, shifted...)
+	}
+
+	return violations
+}
+
+func writeViolations(path string, violations []Violation) (err error) {
+	file, err := atomicfile.Create(path)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = errors.Join(err, file.Close())
+	}()
+
+	writer := csv.NewWriter(file)
+	writer.Comma = '\t'
+
+	if err = writer.Write([]string{"slug", "text", "reason", "value"}); err != nil {
+		return err
+	}
+
+	for _, violation := range violations {
+		if err = writer.Write([]string{violation.Slug, violation.Text, violation.Reason, violation.Value}); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	if err = writer.Error(); err != nil {
+		return err
+	}
+
+	return file.Commit()
+}
+
+// Run compares versions, writes comparison and regressions files and returns ErrRegression on violations
+func Run(resultsPath, previous, current string, cfg *config.CompareConfig) (*Report, error) {
+	report, err := Compare(resultsPath, previous, current, cfg.CompareTopK)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = report.Write(ComparisonFilePath(resultsPath, current)); err != nil {
+		return nil, err
+	}
+
+	violations := report.Judge(cfg)
+	regressionsPath := RegressionsFilePath(resultsPath, current)
+
+	if err = writeViolations(regressionsPath, violations); err != nil {
+		return nil, err
+	}
+
+	if len(violations) > 0 {
+		return report, ErrWrapRegression(len(violations), regressionsPath)
+	}
+
+	return report, nil
+}
@@ -7,8 +7,16 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 	"unicode/utf8"
+
+	"wbx-script/searchType/balancer"
+	"wbx-script/searchType/cache"
+	"wbx-script/searchType/notify"
+	"wbx-script/searchType/sampling"
+
+	"github.com/robfig/cron/v3"
 )
 
 const (
@@ -20,29 +28,105 @@ const (
 	defaultPagePoolSize      = 10
 	defaultScreenshotTimeout = time.Second * 30
 	defaultCsvSeparator      = "\t"
+	defaultListenAddress     = ":8080"
+	defaultQueueSize         = 100
+	defaultVisualizeWait     = time.Second * 30
+	defaultServeVersionName  = "serve"
+	defaultVersionLayout     = "20060102T1504"
+	defaultKeepVersions      = 7
+	defaultCompareTopK       = 10
+	disabledThreshold        = -1
+)
+
+// checkedScheduledRuns is number of following runs which shouldn't share version name
+const checkedScheduledRuns = 100
+
+const (
+	// ServeCommand is first argument which starts http service instead of batch run
+	ServeCommand = "serve"
+	// ScheduleCommand is first argument which starts batch runs by cron expression
+	ScheduleCommand = "schedule"
+	// CompareCommand is first argument which compares existing results versions without requests
+	CompareCommand = "compare"
+)
+
+const comma = ","
+
+const CardsIDsPlaceholder = "{ids}"
+
+const (
+	BrowserChromium = "chromium"
+	BrowserFirefox  = "firefox"
+	BrowserWebkit   = "webkit"
 )
 
 type ScreenshotMakerConfig struct {
-	Width         int
-	Height        int
-	Timeout       time.Duration
-	VisualizerURL *url.URL
-	PoolSize      int
+	Width                 int
+	Height                int
+	Timeout               time.Duration
+	VisualizerURL         *url.URL
+	VisualizerURLTemplate string
+	DirectURL             bool
+	PoolSize              int
+	Browser               string
+	BrowserExecutablePath string
+	BrowserArgs           []string
+	BrowserProxy          *url.URL
+	Locale                string
+	TimezoneID            string
+	SaveHTML              bool
+	SaveHAR               bool
+	SaveConsole           bool
 }
 
 type ExecutorConfig struct {
-	BucketServerURL       *url.URL
+	BucketServerURLs      []*url.URL
+	Balancer              balancer.Config
 	ResultsVersionName    string
 	ResultsPath           string
 	RatePerSecond         int
 	BucketRequestsRetry   int
 	BucketRequestsTimeout time.Duration
 	GoErrGroupLimiter     int
+	ScreenshotsOnly       bool
+	NoScreenshots         bool
+	// CacheDir is empty if cache is disabled
+	CacheDir string
+	CacheTTL time.Duration
 }
 
 type ReaderConfig struct {
 	PathToQueries string
 	CsvSeparator  string
+	Sampling      sampling.Config
+	TextColumn    string
+	QueryColumn   string
+	IDColumn      string
+	NoHeader      bool
+}
+
+type ServerConfig struct {
+	Serve         bool
+	ListenAddress string
+	QueueSize     int
+	VisualizeWait time.Duration
+}
+
+type SchedulerConfig struct {
+	Schedule       bool
+	CronExpression string
+	VersionLayout  string
+	KeepVersions   int
+}
+
+// CompareConfig sets comparison of results versions, negative thresholds are disabled
+type CompareConfig struct {
+	Compare           bool
+	PreviousVersion   string
+	CompareTopK       int
+	MinOverlap        float64
+	MaxEmpty          int
+	MaxCategoryShifts int
 }
 
 type LoggerConfig struct {
@@ -54,6 +138,10 @@ type Config struct {
 	ExecutorConfig
 	ReaderConfig
 	LoggerConfig
+	ServerConfig
+	SchedulerConfig
+	CompareConfig
+	Webhook notify.Config
 }
 
 func parseEnvironment() (int, error) {
@@ -77,21 +165,73 @@ func parseEnvironment() (int, error) {
 
 func Parse() (*Config, error) {
 	cfg := Config{}
+	args := os.Args[1:]
+
+	if len(args) > 0 {
+		switch args[0] {
+		case ServeCommand:
+			cfg.Serve = true
+			args = args[1:]
+		case ScheduleCommand:
+			cfg.Schedule = true
+			args = args[1:]
+		case CompareCommand:
+			cfg.Compare = true
+			args = args[1:]
+		}
+	}
+
 	flag.IntVar(&cfg.RatePerSecond, "rps", defaultRps, "Number of requests per second")
 	flag.IntVar(&cfg.BucketRequestsRetry, "bucket-retry", defaultRetry, "Number of request's retry to bucket server")
 	flag.DurationVar(&cfg.BucketRequestsTimeout, "bucket-timeout", defaultTimeout, "Request's timeout to bucket server")
 	flag.StringVar(&cfg.PathToQueries, "queries-file-path", "", "Path to csv of queries with text, query columns")
 	flag.StringVar(&cfg.ResultsPath, "result-path", "", "Path for saving result files")
 	flag.StringVar(&cfg.ResultsVersionName, "results-version-name", "", "Result is saved to files with this prefix")
+	flag.BoolVar(
+		&cfg.ScreenshotsOnly,
+		"screenshots-only",
+		false,
+		"Make screenshots from existing cards files of results version without requests to bucket server",
+	)
+	flag.BoolVar(&cfg.NoScreenshots, "no-screenshots", false, "Write only cards files without launching browser for screenshots")
 
-	var bucketServerURL, VisualizerURL string
+	var bucketServerURL, VisualizerURL, browserArgs, browserProxy, webhookURL string
 
-	flag.StringVar(&bucketServerURL, "bucket-server-url", "", "URL to bucket server")
+	flag.StringVar(&bucketServerURL, "bucket-server-url", "", "Comma separated URLs of bucket server replicas")
+	flag.StringVar(
+		&cfg.Balancer.Strategy,
+		"balance",
+		balancer.StrategyRoundRobin,
+		"Balancing of requests over bucket server replicas: round-robin|least-inflight",
+	)
+	flag.IntVar(&cfg.Balancer.MaxFails, "max-fails", balancer.DefaultMaxFails, "Consecutive failures after which replica is ejected")
+	flag.DurationVar(&cfg.Balancer.EjectFor, "eject-for", balancer.DefaultEjectFor, "Time of replica ejection(duration format)")
+	flag.IntVar(&cfg.Balancer.Failover, "failover", balancer.DefaultFailover, "Number of other replicas tried after failed request")
 	flag.StringVar(&VisualizerURL, "visualizer-server-url", "", "URL to visualizer for screenshots")
 	flag.IntVar(&cfg.Width, "screenshot-width", defaultWidthScale, "Scale of screenshots by width")
 	flag.IntVar(&cfg.Height, "screenshot-height", defaultHeightScale, "Scale of screenshots by height")
 	flag.IntVar(&cfg.PoolSize, "browser-pool-size", defaultPagePoolSize, "Pool of pages on browser to make screenshots")
 	flag.DurationVar(&cfg.Timeout, "screenshot-timeout", defaultScreenshotTimeout, "Timeout to make screenshot on chromium driver")
+	flag.BoolVar(
+		&cfg.DirectURL,
+		"visualizer-direct-url",
+		false,
+		"Visualizer URL is a template with "+CardsIDsPlaceholder+" placeholder, page is loaded without form filling",
+	)
+	flag.StringVar(&cfg.Browser, "browser", BrowserChromium, "Browser engine for screenshots: chromium|firefox|webkit")
+	flag.StringVar(
+		&cfg.BrowserExecutablePath,
+		"browser-executable-path",
+		"",
+		"Path to locally installed browser instead of downloaded by playwright",
+	)
+	flag.StringVar(&browserArgs, "browser-args", "", "Extra browser launch arguments separated by comma")
+	flag.StringVar(&browserProxy, "browser-proxy", "", "Proxy URL for browser, credentials can be set as user:password@")
+	flag.StringVar(&cfg.Locale, "browser-locale", "", "Locale of browser pages, e.g. ru-RU")
+	flag.StringVar(&cfg.TimezoneID, "browser-timezone", "", "Timezone of browser pages, e.g. Europe/Moscow")
+	flag.BoolVar(&cfg.SaveHTML, "save-html", false, "Save rendered DOM HTML next to each screenshot")
+	flag.BoolVar(&cfg.SaveHAR, "save-har", false, "Save HAR file of network requests next to each screenshot")
+	flag.BoolVar(&cfg.SaveConsole, "save-console", false, "Save browser console messages and page errors next to each screenshot")
 	flag.DurationVar(&cfg.LogPeriod, "logger-period", defaultTimeout, "Period of logger's printing(duration format)")
 	flag.StringVar(
 		&cfg.CsvSeparator,
@@ -100,16 +240,91 @@ func Parse() (*Config, error) {
 		"Separator of csv file with text of search and query part of url(writing)",
 	)
 
-	flag.Parse()
+	flag.StringVar(&cfg.TextColumn, "text-column", "", "Header name or index of text column, first column by default")
+	flag.StringVar(&cfg.QueryColumn, "query-column", "", "Header name or index of query column, second column by default")
+	flag.StringVar(&cfg.IDColumn, "id-column", "", "Header name or index of optional id column")
+	flag.BoolVar(&cfg.NoHeader, "no-header", false, "Queries file has no header, columns are set by index")
+	flag.StringVar(&cfg.Sampling.Mode, "sample-mode", sampling.ModeNone, "Sampling of queries: first|random|nth")
+	flag.IntVar(&cfg.Sampling.Size, "sample-size", 0, "Number of sampled queries, step for nth sample mode")
+	flag.Int64Var(&cfg.Sampling.Seed, "sample-seed", 0, "Seed of random sample mode")
+	flag.StringVar(&cfg.ListenAddress, "listen", defaultListenAddress, "Address of http service in serve mode")
+	flag.IntVar(&cfg.QueueSize, "queue-size", defaultQueueSize, "Max number of waiting visualize jobs in serve mode")
+	flag.DurationVar(
+		&cfg.VisualizeWait,
+		"visualize-wait",
+		defaultVisualizeWait,
+		"Time to wait for job result in visualize request, job status url is returned after it",
+	)
+
+	flag.StringVar(&cfg.CronExpression, "cron", "", "Cron expression of scheduled runs, e.g. '0 3 * * *'")
+	flag.StringVar(
+		&cfg.VersionLayout,
+		"version-layout",
+		defaultVersionLayout,
+		"Go time layout of results version name of scheduled runs",
+	)
+	flag.IntVar(&cfg.KeepVersions, "keep-versions", defaultKeepVersions, "Number of scheduled versions kept per query folder, 0 keeps all")
+	flag.IntVar(&cfg.CompareTopK, "compare-top-k", defaultCompareTopK, "Number of first cards for overlap in comparison of versions")
+	flag.StringVar(&cfg.PreviousVersion, "previous-version", "", "Results version which is compared with results-version-name")
+	flag.Float64Var(
+		&cfg.MinOverlap,
+		"min-overlap",
+		disabledThreshold,
+		"Min overlap of first cards of changed query in comparison, negative value disables check",
+	)
+	flag.IntVar(&cfg.MaxEmpty, "max-empty", disabledThreshold, "Max number of queries with empty cards in comparison, negative value disables check")
+	flag.IntVar(
+		&cfg.MaxCategoryShifts,
+		"max-category-shifts",
+		disabledThreshold,
+		"Max number of queries which category has changed in comparison, negative value disables check",
+	)
+
+	var noCache bool
+
+	flag.StringVar(&cfg.CacheDir, "cache-dir", "", "Directory of cached responses of bucket server, caching is disabled if empty")
+	flag.DurationVar(&cfg.CacheTTL, "cache-ttl", cache.DefaultTTL, "Time to live of cached response(duration format)")
+	flag.BoolVar(&noCache, "no-cache", false, "Send all requests to bucket server without cache")
+	flag.StringVar(&webhookURL, "webhook-url", "", "URL for POST of run summary on finish, failure or interruption")
+	flag.StringVar(
+		&cfg.Webhook.TemplatePath,
+		"webhook-template",
+		"",
+		"Path to text/template of webhook body, JSON summary is sent by default",
+	)
+
+	if err := flag.CommandLine.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if noCache {
+		cfg.CacheDir = ""
+	}
+
+	if cfg.Serve && cfg.ResultsVersionName == "" {
+		cfg.ResultsVersionName = defaultServeVersionName
+	}
 
 	if cfg.ResultsPath == "" {
 		cfg.ResultsPath = filepath.Dir(cfg.PathToQueries)
 	}
 
+	if browserArgs != "" {
+		cfg.BrowserArgs = strings.Split(browserArgs, comma)
+	}
+
 	if err := cfg.validate(bucketServerURL, VisualizerURL); err != nil {
 		log.Fatal(err)
 	}
 
+	if err := cfg.validateBrowser(browserProxy); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := cfg.validateWebhook(webhookURL); err != nil {
+		log.Fatal(err)
+	}
+
 	var err error
 
 	cfg.GoErrGroupLimiter, err = parseEnvironment()
@@ -135,9 +350,13 @@ func parseURL(urlStr, paramName string) (*url.URL, error) {
 }
 
 func (c *Config) validatePaths() error {
-	if info, err := os.Stat(c.PathToQueries); err != nil || info.IsDir() {
-		return ErrWrapInvalidParameter("queries-file-path", err)
-	} else if info, err = os.Stat(c.ResultsPath); err != nil || !info.IsDir() {
+	if !c.ScreenshotsOnly && !c.Serve && !c.Compare {
+		if info, err := os.Stat(c.PathToQueries); err != nil || info.IsDir() {
+			return ErrWrapInvalidParameter("queries-file-path", err)
+		}
+	}
+
+	if info, err := os.Stat(c.ResultsPath); err != nil || !info.IsDir() {
 		return ErrWrapInvalidParameter("result-path", err)
 	}
 
@@ -151,17 +370,35 @@ func (c *Config) validate(bucketURLStr, visualizerURLStr string) error {
 
 	var err error
 
-	c.BucketServerURL, err = parseURL(bucketURLStr, "bucket-server-url")
-	if err != nil {
-		return err
+	if !c.ScreenshotsOnly && !c.Compare {
+		if err = c.validateBucket(bucketURLStr); err != nil {
+			return err
+		}
 	}
 
-	c.VisualizerURL, err = parseURL(visualizerURLStr, "visualizer-server-url")
-	if err != nil {
-		return err
+	if !c.NoScreenshots && !c.Compare {
+		if err = c.validateVisualizer(visualizerURLStr); err != nil {
+			return err
+		}
 	}
 
 	switch {
+	case c.NoScreenshots && c.ScreenshotsOnly:
+		return ErrWrapInvalidParameter("no-screenshots", ErrModesConflict)
+	case c.Serve && c.ScreenshotsOnly:
+		return ErrWrapInvalidParameter("screenshots-only", ErrModesConflict)
+	case c.Serve && c.ListenAddress == "":
+		return ErrWrapInvalidParameter("listen", nil)
+	case c.Serve && c.QueueSize <= 0:
+		return ErrWrapInvalidParameter("queue-size", nil)
+	case c.Serve && c.VisualizeWait < 0:
+		return ErrWrapInvalidParameter("visualize-wait", nil)
+	case c.Schedule && c.ScreenshotsOnly:
+		return ErrWrapInvalidParameter("schedule", ErrModesConflict)
+	case c.Compare && c.ScreenshotsOnly:
+		return ErrWrapInvalidParameter("compare", ErrModesConflict)
+	case c.Compare && c.PreviousVersion == "":
+		return ErrWrapInvalidParameter("previous-version", nil)
 	case c.RatePerSecond <= 0:
 		return ErrWrapInvalidParameter("rps", nil)
 	case c.Timeout <= 0:
@@ -174,15 +411,199 @@ func (c *Config) validate(bucketURLStr, visualizerURLStr string) error {
 		return ErrWrapInvalidParameter("screenshot-width", nil)
 	case c.PoolSize <= 0:
 		return ErrWrapInvalidParameter("browser-pool-size", nil)
-	case c.ResultsVersionName == "":
+	case c.ResultsVersionName == "" && !c.Schedule:
 		return ErrWrapInvalidParameter("results-version-name", nil)
 	case c.BucketRequestsRetry < 0:
 		return ErrWrapInvalidParameter("bucket-retry", nil)
 	case c.BucketRequestsTimeout <= 0:
 		return ErrWrapInvalidParameter("bucket-timeout", nil)
+	case c.CacheTTL <= 0:
+		return ErrWrapInvalidParameter("cache-ttl", nil)
+	case c.Schedule && c.CacheDir != "":
+		return ErrWrapInvalidParameter("cache-dir", ErrCacheInSchedule)
 	case utf8.RuneCountInString(c.CsvSeparator) != 1:
 		return ErrWrapInvalidParameter("csv-separator", nil)
 	}
 
+	if c.Schedule {
+		if err = c.validateScheduler(); err != nil {
+			return err
+		}
+	}
+
+	if c.Schedule || c.Compare {
+		if err = c.validateCompare(); err != nil {
+			return err
+		}
+	}
+
+	if err = c.Sampling.Validate(false); err != nil {
+		return ErrWrapInvalidParameter("sample-mode", err)
+	}
+
+	if c.NoHeader {
+		for name, spec := range map[string]string{
+			"text-column":  c.TextColumn,
+			"query-column": c.QueryColumn,
+			"id-column":    c.IDColumn,
+		} {
+			if _, errIndex := strconv.Atoi(spec); spec != "" && errIndex != nil {
+				return ErrWrapInvalidParameter(name, errIndex)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *Config) validateBucket(bucketURLStr string) error {
+	endpoints := balancer.SplitEndpoints(bucketURLStr)
+
+	if len(endpoints) == 0 {
+		return ErrWrapInvalidParameter("bucket-server-url", ErrEmptyURL)
+	}
+
+	c.BucketServerURLs = make([]*url.URL, 0, len(endpoints))
+
+	for _, endpoint := range endpoints {
+		u, err := parseURL(endpoint, "bucket-server-url")
+		if err != nil {
+			return err
+		}
+
+		c.BucketServerURLs = append(c.BucketServerURLs, u)
+	}
+
+	if err := c.Balancer.Validate(); err != nil {
+		return ErrWrapInvalidParameter("balance", err)
+	}
+
+	return nil
+}
+
+func (c *Config) validateScheduler() error {
+	schedule, err := cron.ParseStandard(c.CronExpression)
+
+	if err != nil {
+		return ErrWrapInvalidParameter("cron", err)
+	}
+
+	// version is taken from beginning of file names till underscore
+	if versionName := time.Now().Format(c.VersionLayout); versionName == "" ||
+		strings.ContainsAny(versionName, "_"+string(filepath.Separator)) {
+		return ErrWrapInvalidParameter("version-layout", ErrVersionLayout)
+	}
+
+	if hasVersionCollisions(schedule, c.VersionLayout) {
+		return ErrWrapInvalidParameter("version-layout", ErrVersionCollision)
+	}
+
+	if c.KeepVersions < 0 {
+		return ErrWrapInvalidParameter("keep-versions", nil)
+	}
+
+	return nil
+}
+
+// hasVersionCollisions checks following scheduled runs, runs with the same version name overwrite each other
+func hasVersionCollisions(schedule cron.Schedule, layout string) bool {
+	names := make(map[string]struct{}, checkedScheduledRuns)
+	activation := time.Now()
+
+	for range checkedScheduledRuns {
+		activation = schedule.Next(activation)
+		name := activation.Format(layout)
+
+		if _, exists := names[name]; exists {
+			return true
+		}
+
+		names[name] = struct{}{}
+	}
+
+	return false
+}
+
+func (c *Config) validateCompare() error {
+	switch {
+	case c.CompareTopK <= 0:
+		return ErrWrapInvalidParameter("compare-top-k", nil)
+	case c.MinOverlap > 1:
+		return ErrWrapInvalidParameter("min-overlap", nil)
+	}
+
+	return nil
+}
+
+func (c *Config) validateWebhook(webhookURLStr string) error {
+	if webhookURLStr == "" {
+		if c.Webhook.TemplatePath != "" {
+			return ErrWrapInvalidParameter("webhook-template", ErrNoWebhookURL)
+		}
+
+		return nil
+	}
+
+	var err error
+
+	if c.Webhook.URL, err = parseURL(webhookURLStr, "webhook-url"); err != nil {
+		return err
+	}
+
+	if c.Webhook.TemplatePath != "" {
+		if info, errStat := os.Stat(c.Webhook.TemplatePath); errStat != nil || info.IsDir() {
+			return ErrWrapInvalidParameter("webhook-template", errStat)
+		}
+	}
+
+	return nil
+}
+
+func (c *Config) validateVisualizer(visualizerURLStr string) error {
+	var err error
+
+	c.VisualizerURL, err = parseURL(visualizerURLStr, "visualizer-server-url")
+	if err != nil {
+		return err
+	}
+
+	if c.DirectURL {
+		if !strings.Contains(visualizerURLStr, CardsIDsPlaceholder) {
+			return ErrWrapInvalidParameter("visualizer-server-url", ErrNoPlaceholder)
+		}
+
+		c.VisualizerURLTemplate = visualizerURLStr
+	}
+
+	return nil
+}
+
+func (c *Config) validateBrowser(proxyURLStr string) error {
+	switch c.Browser {
+	case BrowserChromium, BrowserFirefox, BrowserWebkit:
+	default:
+		return ErrWrapInvalidParameter("browser", ErrUnknownBrowser)
+	}
+
+	if c.BrowserExecutablePath != "" {
+		if info, err := os.Stat(c.BrowserExecutablePath); err != nil || info.IsDir() {
+			return ErrWrapInvalidParameter("browser-executable-path", err)
+		}
+	}
+
+	if proxyURLStr != "" {
+		var err error
+
+		if c.BrowserProxy, err = parseURL(proxyURLStr, "browser-proxy"); err != nil {
+			return err
+		}
+	}
+
+	if c.TimezoneID != "" {
+		if _, err := time.LoadLocation(c.TimezoneID); err != nil {
+			return ErrWrapInvalidParameter("browser-timezone", err)
+		}
+	}
+
 	return nil
 }
@@ -12,3 +12,17 @@ func ErrWrapInvalidParameter(name string, err error) error {
 }
 
 var ErrEmptyURL = errors.New("empty schema or host of URL")
+
+var ErrNoPlaceholder = errors.New("there is no " + CardsIDsPlaceholder + " placeholder in URL template")
+
+var ErrUnknownBrowser = errors.New("unknown browser engine, expected chromium, firefox or webkit")
+
+var ErrModesConflict = errors.New("screenshots-only mode cannot be used together with no-screenshots, serve, schedule or compare modes")
+
+var ErrVersionLayout = errors.New("version layout should give non-empty name without underscores and path separators")
+
+var ErrVersionCollision = errors.New("version layout should give different names to runs of cron expression")
+
+var ErrNoWebhookURL = errors.New("webhook template cannot be used without webhook URL")
+
+var ErrCacheInSchedule = errors.New("cache cannot be used in schedule mode, compared versions would share stale responses")
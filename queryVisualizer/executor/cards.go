@@ -0,0 +1,73 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"wbx-script/queryVisualizer/customerror"
+	"wbx-script/searchType/logger"
+
+	"golang.org/x/sync/errgroup"
+)
+
+func (e *Executor) RunScreenshots(ctx context.Context) (err error) {
+	defer func() {
+		if err != nil {
+			err = customerror.ErrWrapStack("RunScreenshots", err)
+		}
+
+		logger.Info("Screenshots from cards files have finished")
+	}()
+
+	cardsFiles, err := filepath.Glob(
+		filepath.Join(e.cfg.ResultsPath, resultsFolderName, "*", e.cfg.ResultsVersionName+cardsFileSuffix),
+	)
+
+	if err != nil {
+		return err
+	}
+
+	errGroup, errGroupCtx := errgroup.WithContext(ctx)
+	errGroup.SetLimit(e.cfg.GoErrGroupLimiter)
+
+	defer func() {
+		err = errors.Join(errGroup.Wait(), err)
+	}()
+
+	for _, cardsFile := range cardsFiles {
+		if errGroupCtx.Err() != nil {
+			return errGroupCtx.Err()
+		}
+
+		errGroup.Go(func() error {
+			errScreenshot := e.screenshotCardsFile(cardsFile)
+			e.outcomes.add(errScreenshot)
+
+			if !e.doWeIgnoreTheseMistakes(errScreenshot) {
+				return errScreenshot
+			}
+
+			return nil
+		})
+	}
+
+	return nil
+}
+
+func (e *Executor) screenshotCardsFile(cardsFile string) error {
+	cardsIds, err := os.ReadFile(cardsFile)
+
+	if err != nil {
+		return err
+	}
+
+	if len(cardsIds) == 0 {
+		return fmt.Errorf("cards file %q: %w", cardsFile, ErrEmptyResponse)
+	}
+
+	return e.makerScreenshots.MakeScreenshot(string(cardsIds), strings.TrimSuffix(cardsFile, cardsFileSuffix))
+}
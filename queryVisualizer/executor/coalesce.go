@@ -0,0 +1,113 @@
+package executor
+
+import (
+	"errors"
+	"io"
+	"os"
+
+	"wbx-script/queryVisualizer/screenshots"
+	"wbx-script/searchType/atomicfile"
+	"wbx-script/searchType/logger"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// coalescer lets identical in-flight queries share one bucket request and one screenshot
+type coalescer struct {
+	requests    singleflight.Group
+	screenshots singleflight.Group
+}
+
+// request returns body of bucket response, concurrent calls with the same URL share one HTTP call
+func (c *coalescer) request(urlRequest string, fetch func() ([]byte, error)) ([]byte, error) {
+	// singleflight marks result as shared for all callers, so the leader is remembered to log followers only
+	leader := false
+
+	body, err, shared := c.requests.Do(urlRequest, func() (any, error) {
+		leader = true
+		return fetch()
+	})
+
+	if shared && !leader {
+		logger.Info("bucket request is shared with identical in-flight query", "url", urlRequest)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return body.([]byte), nil
+}
+
+// screenshot makes screenshot once for concurrent calls with the same cards IDs,
+// other callers get the screenshot and debug artifacts hard-linked or copied to their own prefix
+func (c *coalescer) screenshot(
+	maker *screenshots.ScreenshotMaker, cardsIDs, prefixFilePath string,
+) (string, error) {
+	madePrefix, err, _ := c.screenshots.Do(cardsIDs, func() (any, error) {
+		if errScreenshot := maker.MakeScreenshot(cardsIDs, prefixFilePath); errScreenshot != nil {
+			return "", errScreenshot
+		}
+
+		return prefixFilePath, nil
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	if madePrefix == prefixFilePath {
+		return screenshots.ScreenshotFilePath(prefixFilePath), nil
+	}
+
+	logger.Info("screenshot is shared with identical in-flight query", "source", madePrefix)
+
+	// only artifacts of current config are shared, files of earlier runs with other settings are ignored
+	sources := maker.ArtifactFilePaths(madePrefix.(string))
+	targets := maker.ArtifactFilePaths(prefixFilePath)
+
+	for i, source := range sources {
+		if err = linkOrCopy(source, targets[i]); err != nil {
+			return "", err
+		}
+	}
+
+	return screenshots.ScreenshotFilePath(prefixFilePath), nil
+}
+
+// linkOrCopy hard-links source to target and falls back to copy, e.g. across file systems
+func linkOrCopy(source, target string) (err error) {
+	if err = os.Remove(target); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	if os.Link(source, target) == nil {
+		return nil
+	}
+
+	sourceFile, err := os.Open(source)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = errors.Join(err, sourceFile.Close())
+	}()
+
+	targetFile, err := atomicfile.Create(target)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = errors.Join(err, targetFile.Close())
+	}()
+
+	if _, err = io.Copy(targetFile, sourceFile); err != nil {
+		return err
+	}
+
+	return targetFile.Commit()
+}
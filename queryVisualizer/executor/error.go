@@ -9,6 +9,7 @@ var (
 	ErrEmptyResponse  = errors.New("empty response")
 	ErrResponseStatus = errors.New("bad status of response")
 	ErrNoOnBucket     = errors.New("there is no preset on bucket")
+	ErrUnsafePath     = errors.New("result directory is outside of results folder")
 )
 
 func ErrBadResponseStatus(status int, body string) error {
@@ -16,3 +17,7 @@ func ErrBadResponseStatus(status int, body string) error {
 		"please check parameters of request, status - %d, answer - %q: %w", status, body, ErrResponseStatus,
 	)
 }
+
+func ErrWrapUnsafePath(name string) error {
+	return fmt.Errorf("directory name - %q: %w", name, ErrUnsafePath)
+}
@@ -3,6 +3,7 @@ package executor
 import (
 	"bufio"
 	"context"
+	"encoding/csv"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -16,8 +17,14 @@ import (
 
 	"wbx-script/queryVisualizer/config"
 	"wbx-script/queryVisualizer/customerror"
+	"wbx-script/queryVisualizer/reader"
 	"wbx-script/queryVisualizer/screenshots"
+	"wbx-script/queryVisualizer/slug"
+	"wbx-script/searchType/atomicfile"
+	"wbx-script/searchType/balancer"
+	"wbx-script/searchType/cache"
 	"wbx-script/searchType/logger"
+	"wbx-script/searchType/presets"
 
 	"github.com/go-resty/resty/v2"
 	"golang.org/x/sync/errgroup"
@@ -30,24 +37,49 @@ const (
 const (
 	resultsFolderName = "visualizer_results"
 	cardsFileSuffix   = "_cards.ids"
+	inputFileSuffix   = "_input.tsv"
+	manifestSuffix    = "_manifest.json"
 )
 
 const (
-	badPresetMsg = "preset param malformed"
+	badPresetMsg = presets.BadPresetMessage
 )
 
+// Visualization describes written result of single query
+type Visualization struct {
+	Slug           string
+	CardsIDs       string
+	ScreenshotPath string
+}
+
+// ResultsDirectory returns folder of results with query folders inside
+func ResultsDirectory(resultsPath string) string {
+	return filepath.Join(resultsPath, resultsFolderName)
+}
+
+// CardsFilePath returns path of cards file of query folder for results version
+func CardsFilePath(resultsPath, name, versionName string) string {
+	return filepath.Join(ResultsDirectory(resultsPath), name, versionName+cardsFileSuffix)
+}
+
 type Executor struct {
 	cfg              *config.ExecutorConfig
 	client           *resty.Client
-	rateLimit        time.Duration
+	limiter          *time.Ticker
 	makerScreenshots *screenshots.ScreenshotMaker
-	lines            <-chan []string
+	lines            <-chan reader.Line
+	cache            *cache.Cache
+	balancer         *balancer.Balancer
+	coalescer        *coalescer
+	index            *resultsIndex
+	outcomes         *outcomes
 }
 
 func NewExecutor(
 	cfg *config.ExecutorConfig,
 	makerScreenshots *screenshots.ScreenshotMaker,
-	lines <-chan []string,
+	lines <-chan reader.Line,
+	responseCache *cache.Cache,
 ) *Executor {
 	client := resty.New().
 		SetTimeout(cfg.BucketRequestsTimeout).
@@ -57,12 +89,34 @@ func NewExecutor(
 	return &Executor{
 		cfg:              cfg,
 		client:           client,
-		rateLimit:        time.Duration(cfg.RatePerSecond),
+		limiter:          time.NewTicker(time.Second / time.Duration(cfg.RatePerSecond)),
 		makerScreenshots: makerScreenshots,
 		lines:            lines,
+		cache:            responseCache,
+		balancer:         balancer.New(cfg.BucketServerURLs, cfg.Balancer),
+		coalescer:        &coalescer{},
+		outcomes:         newOutcomes(),
 	}
 }
 
+func (e *Executor) Counts() map[string]int {
+	return e.outcomes.get()
+}
+
+// Stop releases rate limiter of executor which is used without Run
+func (e *Executor) Stop() {
+	e.limiter.Stop()
+	e.cache.LogSummary()
+}
+
+// Visualize processes single query with the same rate limit as Run, results index isn't written
+func (e *Executor) Visualize(ctx context.Context, line reader.Line) (Visualization, error) {
+	visualization, err := e.processQuery(ctx, line)
+	e.outcomes.add(err)
+
+	return visualization, err
+}
+
 func (*Executor) doWeIgnoreTheseMistakes(err error) bool {
 	if errors.Is(err, ErrNoOnBucket) || errors.Is(err, ErrEmptyResponse) {
 		// just print to not miss info about
@@ -74,14 +128,19 @@ func (*Executor) doWeIgnoreTheseMistakes(err error) bool {
 }
 
 func (e *Executor) Run(ctx context.Context) (err error) {
-	limiter := time.NewTicker(time.Second / e.rateLimit)
+	e.index, err = newResultsIndex(filepath.Join(e.cfg.ResultsPath, resultsFolderName), e.cfg.ResultsVersionName)
+
+	if err != nil {
+		return err
+	}
+
 	errGroup, errGroupCtx := errgroup.WithContext(ctx)
 	errGroup.SetLimit(e.cfg.GoErrGroupLimiter)
 
 	defer func() {
-		err = errors.Join(errGroup.Wait(), err)
+		err = errors.Join(errGroup.Wait(), e.index.Close(), err)
 
-		limiter.Stop()
+		e.Stop()
 	}()
 
 	for {
@@ -93,9 +152,8 @@ func (e *Executor) Run(ctx context.Context) (err error) {
 
 			errGroup.Go(
 				func() error {
-					errProcess := e.processQuery(
-						errGroupCtx, line[0], line[1], limiter,
-					)
+					_, errProcess := e.processQuery(errGroupCtx, line)
+					e.outcomes.add(errProcess)
 
 					if !e.doWeIgnoreTheseMistakes(errProcess) {
 						return errProcess
@@ -110,55 +168,109 @@ func (e *Executor) Run(ctx context.Context) (err error) {
 	}
 }
 
-func (*Executor) waitLimiterAllowed(ctx context.Context, limiter *time.Ticker) error {
+func (e *Executor) waitLimiterAllowed(ctx context.Context) error {
 	select {
-	case <-limiter.C:
+	case <-e.limiter.C:
 		return nil
 	case <-ctx.Done():
 		return ctx.Err()
 	}
 }
 
-func (e *Executor) processQuery(ctx context.Context, text, query string, limiter *time.Ticker) (err error) {
+func (e *Executor) processQuery(ctx context.Context, line reader.Line) (visualization Visualization, err error) {
 	defer func() {
 		if err != nil {
-			err = customerror.ErrWrapStack(fmt.Sprintf("processQuery for %q", text), err)
+			err = customerror.ErrWrapStack(fmt.Sprintf("processQuery for %q", line.Text), err)
 		}
 
-		logger.Info(fmt.Sprintf("%q query has finished", text))
+		logger.Info(fmt.Sprintf("%q query has finished", line.Text))
 	}()
 
 	var queryParams url.Values
 
-	queryParams, err = url.ParseQuery(query)
+	queryParams, err = url.ParseQuery(line.Query)
 
 	if err != nil {
+		return visualization, err
+	}
+
+	var body []byte
+
+	if body, err = e.requestBucket(ctx, queryParams); err != nil {
+		return visualization, err
+	}
+
+	return e.process(line, body)
+}
+
+// requestBucket returns cached response without waiting for rate limiter,
+// identical in-flight requests are coalesced into one.
+// Key of cache and coalescing is built with the first replica, so it is shared by all replicas
+func (e *Executor) requestBucket(ctx context.Context, queryParams url.Values) ([]byte, error) {
+	key := e.setupURL(e.balancer.Primary(), queryParams).String()
+
+	return e.coalescer.request(key, func() ([]byte, error) {
+		return e.fetchBucket(ctx, key, queryParams)
+	})
+}
+
+func (e *Executor) fetchBucket(ctx context.Context, key string, queryParams url.Values) ([]byte, error) {
+	if body, ok := e.cache.Get(key); ok {
+		return body, nil
+	}
+
+	if err := e.waitLimiterAllowed(ctx); err != nil {
+		return nil, err
+	}
+
+	var body []byte
+
+	// request fails over to other replicas only if replica is unavailable
+	err := e.balancer.Do(ctx, func(endpoint *url.URL) (err error) {
+		body, err = e.requestEndpoint(ctx, e.setupURL(endpoint, queryParams).String(), queryParams)
 		return err
+	})
+
+	if err != nil {
+		return nil, err
 	}
 
-	if errLimiter := e.waitLimiterAllowed(ctx, limiter); errLimiter != nil {
-		return errLimiter
+	// failed caching doesn't fail request
+	if errCache := e.cache.Put(key, body); errCache != nil {
+		logger.Error(errCache.Error())
 	}
 
-	var response *resty.Response
+	return body, nil
+}
 
-	response, err = e.client.R().SetContext(ctx).Get(
-		e.setupURL(queryParams).String(),
-	)
+func (e *Executor) requestEndpoint(ctx context.Context, urlRequest string, queryParams url.Values) ([]byte, error) {
+	response, err := e.client.R().SetContext(ctx).Get(urlRequest)
 
 	if err != nil {
-		return err
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		return nil, balancer.ErrWrapUnavailable(err)
 	}
 
 	if !response.IsSuccess() {
 		if response.StatusCode() == http.StatusBadRequest && response.String() == badPresetMsg {
-			return fmt.Errorf("preset id - %q, error msg - %q: %w", queryParams["preset"], badPresetMsg, ErrNoOnBucket)
+			return nil, fmt.Errorf(
+				"preset id - %q, error msg - %q: %w", queryParams["preset"], badPresetMsg, ErrNoOnBucket,
+			)
 		}
 
-		return ErrBadResponseStatus(response.StatusCode(), response.String())
+		err = ErrBadResponseStatus(response.StatusCode(), response.String())
+
+		if response.StatusCode() >= http.StatusInternalServerError {
+			return nil, balancer.ErrWrapUnavailable(err)
+		}
+
+		return nil, err
 	}
 
-	return e.process(text, response.Body())
+	return response.Body(), nil
 }
 
 func buildPresetsString(products []Product) (string, error) {
@@ -205,8 +317,23 @@ func (*Executor) writeCards(writer *bufio.Writer, responseProducts []Product) (s
 	return cardsIds, err
 }
 
-func (e *Executor) getPrefixFilePath(text string) (string, error) {
-	directory := filepath.Join(e.cfg.ResultsPath, resultsFolderName, text)
+func (e *Executor) ManifestPath() (string, error) {
+	resultsDirectory := filepath.Join(e.cfg.ResultsPath, resultsFolderName)
+
+	if err := os.MkdirAll(resultsDirectory, os.ModePerm); err != nil {
+		return "", err
+	}
+
+	return filepath.Join(resultsDirectory, e.cfg.ResultsVersionName+manifestSuffix), nil
+}
+
+func (e *Executor) getPrefixFilePath(name string) (string, error) {
+	resultsDirectory := filepath.Join(e.cfg.ResultsPath, resultsFolderName)
+	directory := filepath.Join(resultsDirectory, name)
+
+	if filepath.Dir(directory) != resultsDirectory {
+		return "", ErrWrapUnsafePath(name)
+	}
 
 	if err := os.MkdirAll(directory, os.ModePerm); err != nil {
 		return "", err
@@ -236,9 +363,9 @@ func (e *Executor) writePresets(prefixFilePath string, body []byte) (cardsIds st
 		return "", errValidation
 	}
 
-	var file *os.File
+	var file *atomicfile.File
 
-	file, err = os.OpenFile(prefixFilePath+cardsFileSuffix, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.ModePerm)
+	file, err = atomicfile.Create(prefixFilePath + cardsFileSuffix)
 
 	if err != nil {
 		return "", err
@@ -254,28 +381,85 @@ func (e *Executor) writePresets(prefixFilePath string, body []byte) (cardsIds st
 		return "", err
 	}
 
+	if err = file.Commit(); err != nil {
+		return "", err
+	}
+
 	return cardsIds, nil
 }
 
-func (e *Executor) process(text string, body []byte) (err error) {
-	var prefixFilePath, cardsIds string
-	prefixFilePath, err = e.getPrefixFilePath(text)
+func (e *Executor) process(line reader.Line, body []byte) (visualization Visualization, err error) {
+	var prefixFilePath string
+
+	visualization.Slug = slug.Make(line.Text)
+	prefixFilePath, err = e.getPrefixFilePath(visualization.Slug)
 
 	if err != nil {
 		return
 	}
 
-	cardsIds, err = e.writePresets(prefixFilePath, body)
+	if e.index != nil {
+		if err = e.index.add(visualization.Slug, line); err != nil {
+			return
+		}
+	}
 
-	if err != nil {
+	if err = e.writeInputLine(prefixFilePath, line); err != nil {
+		return
+	}
+
+	visualization.CardsIDs, err = e.writePresets(prefixFilePath, body)
+
+	if err != nil || e.makerScreenshots == nil {
 		return
 	}
 
-	return e.makerScreenshots.MakeScreenshot(cardsIds, prefixFilePath)
+	visualization.ScreenshotPath, err = e.coalescer.screenshot(
+		e.makerScreenshots, visualization.CardsIDs, prefixFilePath,
+	)
+
+	return visualization, err
+}
+
+func (*Executor) writeInputLine(prefixFilePath string, line reader.Line) (err error) {
+	if line.ID == "" && len(line.Extra) == 0 {
+		return nil
+	}
+
+	var file *atomicfile.File
+
+	file, err = atomicfile.Create(prefixFilePath + inputFileSuffix)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = errors.Join(err, file.Close())
+	}()
+
+	writer := csv.NewWriter(file)
+	writer.Comma = '\t'
+
+	if err = writer.Write(append([]string{"id", "text", "query"}, line.ExtraColumns...)); err != nil {
+		return err
+	}
+
+	if err = writer.Write(append([]string{line.ID, line.Text, line.Query}, line.Extra...)); err != nil {
+		return err
+	}
+
+	writer.Flush()
+
+	if err = writer.Error(); err != nil {
+		return err
+	}
+
+	return file.Commit()
 }
 
-func (e *Executor) setupURL(queryParams url.Values) *url.URL {
-	bucketURL := *e.cfg.BucketServerURL
+func (*Executor) setupURL(endpoint *url.URL, queryParams url.Values) *url.URL {
+	bucketURL := *endpoint
 	q := bucketURL.Query()
 
 	for param, vals := range queryParams {
@@ -0,0 +1,77 @@
+package executor
+
+import (
+	"encoding/csv"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"wbx-script/queryVisualizer/reader"
+	"wbx-script/searchType/atomicfile"
+)
+
+// index is kept per version like other files of version, so retention and comparison find it by version prefix
+const indexFileSuffix = "_index.csv"
+
+// IndexFilePath returns path of index file which maps query folders to texts for results version
+func IndexFilePath(resultsPath, versionName string) string {
+	return filepath.Join(ResultsDirectory(resultsPath), versionName+indexFileSuffix)
+}
+
+type resultsIndex struct {
+	mu     *sync.Mutex
+	file   *atomicfile.File
+	writer *csv.Writer
+}
+
+func newResultsIndex(directory, versionName string) (*resultsIndex, error) {
+	if err := os.MkdirAll(directory, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	file, err := atomicfile.Create(filepath.Join(directory, versionName+indexFileSuffix))
+
+	if err != nil {
+		return nil, err
+	}
+
+	writer := csv.NewWriter(file)
+
+	if err = writer.Write([]string{"slug", "id", "text", "query"}); err != nil {
+		return nil, errors.Join(file.Close(), err)
+	}
+
+	return &resultsIndex{
+		mu:     &sync.Mutex{},
+		file:   file,
+		writer: writer,
+	}, nil
+}
+
+func (i *resultsIndex) add(slug string, line reader.Line) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if err := i.writer.Write([]string{slug, line.ID, line.Text, line.Query}); err != nil {
+		return err
+	}
+
+	i.writer.Flush()
+
+	return i.writer.Error()
+}
+
+// Close commits index even after failed run, it describes folders which have been already written
+func (i *resultsIndex) Close() error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.writer.Flush()
+
+	if err := i.writer.Error(); err != nil {
+		return errors.Join(err, i.file.Close())
+	}
+
+	return i.file.Commit()
+}
@@ -0,0 +1,52 @@
+package executor
+
+import (
+	"errors"
+	"maps"
+	"sync"
+)
+
+const (
+	outcomeSuccess  = "success"
+	outcomeEmpty    = "empty_response"
+	outcomeNoPreset = "no_preset_on_bucket"
+	outcomeFailed   = "failed"
+)
+
+type outcomes struct {
+	mu     *sync.Mutex
+	counts map[string]int
+}
+
+func newOutcomes() *outcomes {
+	return &outcomes{
+		mu:     &sync.Mutex{},
+		counts: make(map[string]int),
+	}
+}
+
+func (o *outcomes) add(err error) {
+	outcome := outcomeSuccess
+
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrEmptyResponse):
+		outcome = outcomeEmpty
+	case errors.Is(err, ErrNoOnBucket):
+		outcome = outcomeNoPreset
+	default:
+		outcome = outcomeFailed
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.counts[outcome]++
+}
+
+func (o *outcomes) get() map[string]int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return maps.Clone(o.counts)
+}
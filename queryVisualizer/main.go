@@ -2,17 +2,26 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"wbx-script/queryVisualizer/compare"
 	"wbx-script/queryVisualizer/config"
 	"wbx-script/queryVisualizer/executor"
 	"wbx-script/queryVisualizer/reader"
+	"wbx-script/queryVisualizer/scheduler"
 	"wbx-script/queryVisualizer/screenshots"
+	"wbx-script/queryVisualizer/server"
+	"wbx-script/searchType/cache"
 	"wbx-script/searchType/logger"
+	"wbx-script/searchType/manifest"
+	"wbx-script/searchType/notify"
 
 	"golang.org/x/sync/errgroup"
 )
@@ -23,7 +32,7 @@ func newChain(tasks ...func(ctx context.Context) error) chain {
 	return tasks
 }
 
-func (c chain) run(ctx context.Context, limit int) {
+func (c chain) run(ctx context.Context, limit int) error {
 	errGroup, errGroupCtx := errgroup.WithContext(ctx)
 	errGroup.SetLimit(limit)
 
@@ -31,12 +40,23 @@ func (c chain) run(ctx context.Context, limit int) {
 		errGroup.Go(func() error { return c[i](errGroupCtx) })
 	}
 
-	if err := errGroup.Wait(); err != nil {
-		logger.Error(err.Error())
-	}
+	return errGroup.Wait()
 }
 
+const toolName = "queryVisualizer"
+
+// exit codes let jobs worker and CI gate distinguish violated thresholds of comparison from other failures,
+// jobs worker finishes job with regression without retries
+const (
+	failureExitCode    = 1
+	regressionExitCode = 2
+)
+
 func main() {
+	os.Exit(run())
+}
+
+func run() int {
 	cfg, err := config.Parse()
 
 	if err != nil {
@@ -48,32 +68,130 @@ func main() {
 
 	defer logger.GlobalLogger.Stop()
 
-	screenMaker, err := screenshots.NewScreenshotMaker(
-		&cfg.ScreenshotMakerConfig,
-	)
+	signalCtx, signalStop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+
+	defer signalStop()
+
+	switch {
+	case cfg.Serve:
+		err = serve(signalCtx, cfg)
+	case cfg.Schedule:
+		err = scheduler.NewScheduler(cfg, runBatch).Run(signalCtx)
+	case cfg.Compare:
+		err = runCompare(cfg)
+	default:
+		err = runBatch(signalCtx, cfg)
+	}
+
+	if err == nil {
+		return 0
+	}
+
+	logger.Error(err.Error())
+
+	if errors.Is(err, compare.ErrRegression) {
+		return regressionExitCode
+	}
+
+	return failureExitCode
+}
+
+func runCompare(cfg *config.Config) error {
+	report, err := compare.Run(cfg.ResultsPath, cfg.PreviousVersion, cfg.ResultsVersionName, &cfg.CompareConfig)
+
+	if report != nil {
+		logger.Info(fmt.Sprintf(
+			"Version %q has been compared with %q: %v", cfg.ResultsVersionName, cfg.PreviousVersion, report.Counts(),
+		))
+	}
+
+	return err
+}
+
+// newScreenshotMaker returns nil maker if screenshots are disabled
+func newScreenshotMaker(cfg *config.Config) (*screenshots.ScreenshotMaker, func(), error) {
+	if cfg.NoScreenshots {
+		return nil, func() {}, nil
+	}
+
+	screenMaker, err := screenshots.NewScreenshotMaker(&cfg.ScreenshotMakerConfig)
 
 	if err != nil {
-		logger.Error(err.Error())
-		return
+		return nil, nil, err
 	}
 
+	return screenMaker, func() {
+		if errStop := screenMaker.Stop(); errStop != nil {
+			logger.Error(errStop.Error())
+		}
+	}, nil
+}
+
+func serve(ctx context.Context, cfg *config.Config) error {
+	screenMaker, stop, err := newScreenshotMaker(cfg)
+
+	if err != nil {
+		return err
+	}
+
+	defer stop()
+
+	responseCache, err := cache.New(cfg.CacheDir, cfg.CacheTTL)
+
+	if err != nil {
+		return err
+	}
+
+	return server.NewServer(cfg, executor.NewExecutor(&cfg.ExecutorConfig, screenMaker, nil, responseCache)).Run(ctx)
+}
+
+func runBatch(ctx context.Context, cfg *config.Config) (err error) {
+	notifier, err := notify.NewNotifier(cfg.Webhook)
+
+	if err != nil {
+		return err
+	}
+
+	startedAt := time.Now()
+
+	// manifest is nil if run has failed before its manifest was started
+	var runManifest *manifest.Manifest
+
+	// run is notified on every exit path, failed notification doesn't change result of run
 	defer func() {
-		if err = screenMaker.Stop(); err != nil {
-			logger.Error(err.Error())
+		if errSend := notifier.Send(notify.Run{
+			Tool:      toolName,
+			StartedAt: startedAt,
+			Manifest:  runManifest,
+			Err:       err,
+			Output:    executor.ResultsDirectory(cfg.ResultsPath),
+		}); errSend != nil {
+			logger.Error(errSend.Error())
 		}
 	}()
 
-	lines := make(chan []string)
+	responseCache, err := cache.New(cfg.CacheDir, cfg.CacheTTL)
+
+	if err != nil {
+		return err
+	}
+
+	screenMaker, stop, err := newScreenshotMaker(cfg)
+
+	if err != nil {
+		return err
+	}
+
+	defer stop()
+
+	lines := make(chan reader.Line)
 	exec := executor.NewExecutor(
 		&cfg.ExecutorConfig,
 		screenMaker,
 		lines,
+		responseCache,
 	)
 
-	signalCtx, signalStop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
-
-	defer signalStop()
-
 	app := newChain(
 		func(c context.Context) error {
 			return reader.Read(c, &cfg.ReaderConfig, lines)
@@ -81,5 +199,22 @@ func main() {
 		exec.Run,
 	)
 
-	app.run(signalCtx, cfg.GoErrGroupLimiter)
+	if cfg.ScreenshotsOnly {
+		app = newChain(exec.RunScreenshots)
+	}
+
+	manifestPath, err := exec.ManifestPath()
+
+	if err != nil {
+		return err
+	}
+
+	if runManifest, err = manifest.Start(manifestPath, toolName, cfg.PathToQueries); err != nil {
+		return err
+	}
+
+	errRun := app.run(ctx, cfg.GoErrGroupLimiter)
+	runManifest.SetCounts(exec.Counts())
+
+	return errors.Join(errRun, runManifest.Finish(errRun))
 }
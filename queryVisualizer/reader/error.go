@@ -5,8 +5,15 @@ import (
 	"fmt"
 )
 
-var ErrColumnsAmount = errors.New("wrong number of columns")
+var (
+	ErrColumnsAmount = errors.New("wrong number of columns")
+	ErrNoColumn      = errors.New("there is no column in header")
+)
+
+func ErrBadNumberOfColumns(needed, numberOfColumns int) error {
+	return fmt.Errorf("it should be at least '%d', have got '%d': %w", needed, numberOfColumns, ErrColumnsAmount)
+}
 
-func ErrBadNumberOfColumns(numberOfColumns int) error {
-	return fmt.Errorf("it doesn't equal 2(text, query), have got '%d': %w", numberOfColumns, ErrColumnsAmount)
+func ErrWrapNoColumn(name string) error {
+	return fmt.Errorf("column - %q: %w", name, ErrNoColumn)
 }
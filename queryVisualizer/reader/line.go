@@ -0,0 +1,90 @@
+package reader
+
+import (
+	"slices"
+	"strconv"
+)
+
+type Line struct {
+	ID           string
+	Text         string
+	Query        string
+	Extra        []string
+	ExtraColumns []string
+}
+
+type columns struct {
+	text   int
+	query  int
+	id     int
+	header []string
+}
+
+func resolveColumn(spec string, header []string, defaultIndex int) (int, error) {
+	if spec == "" {
+		return defaultIndex, nil
+	}
+
+	if index, err := strconv.Atoi(spec); err == nil && index >= 0 {
+		return index, nil
+	}
+
+	if index := slices.Index(header, spec); index >= 0 {
+		return index, nil
+	}
+
+	return 0, ErrWrapNoColumn(spec)
+}
+
+func resolveColumns(textSpec, querySpec, idSpec string, header []string) (columns, error) {
+	var (
+		c   = columns{id: -1, header: header}
+		err error
+	)
+
+	if c.text, err = resolveColumn(textSpec, header, 0); err != nil {
+		return c, err
+	}
+
+	if c.query, err = resolveColumn(querySpec, header, 1); err != nil {
+		return c, err
+	}
+
+	if c.id, err = resolveColumn(idSpec, header, -1); err != nil {
+		return c, err
+	}
+
+	return c, nil
+}
+
+func (c columns) toLine(record []string) (Line, error) {
+	if needed := max(c.text, c.query, c.id) + 1; len(record) < needed {
+		return Line{}, ErrBadNumberOfColumns(needed, len(record))
+	}
+
+	line := Line{
+		Text:  record[c.text],
+		Query: record[c.query],
+	}
+
+	if c.id >= 0 {
+		line.ID = record[c.id]
+	}
+
+	for i, value := range record {
+		if i == c.text || i == c.query || i == c.id {
+			continue
+		}
+
+		name := "column_" + strconv.Itoa(i)
+
+		if i < len(c.header) {
+			name = c.header[i]
+		}
+
+		line.Extra = append(line.Extra, value)
+		line.ExtraColumns = append(line.ExtraColumns, name)
+	}
+
+	return line, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/csv"
 	"errors"
+	"fmt"
 	"io"
 	"net/url"
 	"os"
@@ -13,13 +14,14 @@ import (
 	"wbx-script/queryVisualizer/config"
 	"wbx-script/queryVisualizer/customerror"
 	"wbx-script/searchType/logger"
+	"wbx-script/searchType/sampling"
 )
 
 const (
 	semicolon = ";"
 )
 
-func Read(ctx context.Context, cfg *config.ReaderConfig, lines chan<- []string) (err error) {
+func Read(ctx context.Context, cfg *config.ReaderConfig, lines chan<- Line) (err error) {
 	defer func() {
 		close(lines)
 
@@ -42,35 +44,83 @@ func Read(ctx context.Context, cfg *config.ReaderConfig, lines chan<- []string)
 	csvReader := csv.NewReader(f)
 	sepRune, _ := utf8.DecodeRuneInString(cfg.CsvSeparator)
 	csvReader.Comma = sepRune
-	isHeader := true
+	csvReader.FieldsPerRecord = -1
 
-	var record []string
+	var header []string
 
-	for {
+	if !cfg.NoHeader {
+		if header, err = csvReader.Read(); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			return err
+		}
+	}
+
+	cols, err := resolveColumns(cfg.TextColumn, cfg.QueryColumn, cfg.IDColumn, header)
+	if err != nil {
+		return err
+	}
+
+	sampler := sampling.NewSampler[Line](cfg.Sampling)
+
+	var (
+		record     []string
+		line       Line
+		pass, done bool
+		parseErr   *csv.ParseError
+	)
+
+	for !done {
 		record, err = csvReader.Read()
 
 		if errors.Is(err, io.EOF) {
-			return nil
+			break
+		}
+
+		if errors.As(err, &parseErr) {
+			logger.Error(fmt.Sprintf("Row has been skipped: %s", err))
+			continue
 		}
 
 		if err != nil {
 			return err
 		}
 
-		if isHeader {
-			isHeader = false
+		if line, err = cols.toLine(record); err != nil {
+			row, _ := csvReader.FieldPos(0)
+			logger.Error(fmt.Sprintf("Row %d has been skipped: %s", row, err))
+
 			continue
 		}
 
-		if len(record) != 2 {
-			return ErrBadNumberOfColumns(len(record))
+		line.Query = strings.ReplaceAll(line.Query, semicolon, url.PathEscape(semicolon))
+
+		if pass, done = sampler.Add(line, 1); !pass {
+			continue
 		}
 
-		record[1] = strings.ReplaceAll(record[1], semicolon, url.PathEscape(semicolon))
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case lines <- record:
+		if err = send(ctx, lines, line); err != nil {
+			return err
 		}
 	}
+
+	for _, line = range sampler.Rest() {
+		if err = send(ctx, lines, line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func send(ctx context.Context, lines chan<- Line, line Line) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case lines <- line:
+	}
+
+	return nil
 }
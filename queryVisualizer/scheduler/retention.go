@@ -0,0 +1,123 @@
+package scheduler
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const versionSeparator = "_"
+
+type version struct {
+	name      string
+	createdAt time.Time
+	files     []string
+}
+
+// listVersions groups files of directory by versions which match layout, the newest version is first
+func listVersions(directory, layout string) ([]version, error) {
+	entries, err := os.ReadDir(directory)
+
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]*version)
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		name, _, ok := strings.Cut(entry.Name(), versionSeparator)
+
+		if !ok {
+			continue
+		}
+
+		createdAt, errParse := time.Parse(layout, name)
+
+		if errParse != nil {
+			continue
+		}
+
+		if _, exists := byName[name]; !exists {
+			byName[name] = &version{name: name, createdAt: createdAt}
+		}
+
+		byName[name].files = append(byName[name].files, filepath.Join(directory, entry.Name()))
+	}
+
+	versions := make([]version, 0, len(byName))
+
+	for _, v := range byName {
+		versions = append(versions, *v)
+	}
+
+	slices.SortFunc(versions, func(a, b version) int {
+		return b.createdAt.Compare(a.createdAt)
+	})
+
+	return versions, nil
+}
+
+// previousVersion returns the newest version created before current one
+func previousVersion(directory, layout, current string) (string, error) {
+	currentAt, err := time.Parse(layout, current)
+
+	if err != nil {
+		return "", err
+	}
+
+	versions, err := listVersions(directory, layout)
+
+	if err != nil {
+		return "", err
+	}
+
+	for _, v := range versions {
+		if v.createdAt.Before(currentAt) {
+			return v.name, nil
+		}
+	}
+
+	return "", nil
+}
+
+// prune removes files of old versions in results folder and every query folder,
+// versions with names out of layout aren't touched
+func prune(resultsDirectory, layout string, keep int) error {
+	entries, err := os.ReadDir(resultsDirectory)
+
+	if err != nil {
+		return err
+	}
+
+	directories := []string{resultsDirectory}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			directories = append(directories, filepath.Join(resultsDirectory, entry.Name()))
+		}
+	}
+
+	for _, directory := range directories {
+		versions, errList := listVersions(directory, layout)
+
+		if errList != nil {
+			err = errors.Join(err, errList)
+			continue
+		}
+
+		for _, old := range versions[min(keep, len(versions)):] {
+			for _, file := range old.files {
+				err = errors.Join(err, os.Remove(file))
+			}
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,93 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"wbx-script/queryVisualizer/compare"
+	"wbx-script/queryVisualizer/config"
+	"wbx-script/queryVisualizer/executor"
+	"wbx-script/searchType/logger"
+
+	"github.com/robfig/cron/v3"
+)
+
+// RunFunc makes single batch run with results version name of config
+type RunFunc func(ctx context.Context, cfg *config.Config) error
+
+type Scheduler struct {
+	cfg *config.Config
+	run RunFunc
+}
+
+func NewScheduler(cfg *config.Config, run RunFunc) *Scheduler {
+	return &Scheduler{
+		cfg: cfg,
+		run: run,
+	}
+}
+
+func (s *Scheduler) Run(ctx context.Context) error {
+	// next run is skipped if previous one hasn't finished yet
+	cronRunner := cron.New(cron.WithChain(cron.SkipIfStillRunning(cron.DiscardLogger)))
+
+	if _, err := cronRunner.AddFunc(s.cfg.CronExpression, func() { s.tick(ctx) }); err != nil {
+		return err
+	}
+
+	cronRunner.Start()
+	logger.Info(fmt.Sprintf("Scheduler has started with %q cron expression", s.cfg.CronExpression))
+
+	<-ctx.Done()
+	<-cronRunner.Stop().Done()
+
+	logger.Info("Scheduler has finished")
+
+	return nil
+}
+
+func (s *Scheduler) tick(ctx context.Context) {
+	if ctx.Err() != nil {
+		return
+	}
+
+	cfgRun := *s.cfg
+	cfgRun.ResultsVersionName = time.Now().Format(s.cfg.VersionLayout)
+
+	logger.Info(fmt.Sprintf("Scheduled run of %q version has started", cfgRun.ResultsVersionName))
+
+	// comparison and pruning are skipped to not lose versions because of partial results
+	if err := s.run(ctx, &cfgRun); err != nil {
+		logger.Error(fmt.Sprintf("Scheduled run of %q version has failed: %s", cfgRun.ResultsVersionName, err))
+		return
+	}
+
+	if err := s.compare(cfgRun.ResultsVersionName); err != nil {
+		logger.Error(err.Error())
+	}
+
+	if s.cfg.KeepVersions == 0 {
+		return
+	}
+
+	if err := prune(executor.ResultsDirectory(s.cfg.ResultsPath), s.cfg.VersionLayout, s.cfg.KeepVersions); err != nil {
+		logger.Error(err.Error())
+	}
+}
+
+func (s *Scheduler) compare(current string) error {
+	previous, err := previousVersion(executor.ResultsDirectory(s.cfg.ResultsPath), s.cfg.VersionLayout, current)
+
+	if err != nil || previous == "" {
+		return err
+	}
+
+	report, err := compare.Run(s.cfg.ResultsPath, previous, current, &s.cfg.CompareConfig)
+
+	if report != nil {
+		logger.Info(fmt.Sprintf("Version %q has been compared with %q: %v", current, previous, report.Counts()))
+	}
+
+	return err
+}
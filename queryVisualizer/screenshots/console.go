@@ -0,0 +1,39 @@
+package screenshots
+
+import (
+	"strings"
+	"sync"
+)
+
+const newline = "\n"
+
+type consoleLog struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (c *consoleLog) add(line string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lines = append(c.lines, line)
+}
+
+func (c *consoleLog) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lines = c.lines[:0]
+}
+
+func (c *consoleLog) drain() []byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.lines) == 0 {
+		return nil
+	}
+
+	data := []byte(strings.Join(c.lines, newline) + newline)
+	c.lines = c.lines[:0]
+
+	return data
+}
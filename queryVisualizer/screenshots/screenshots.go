@@ -1,23 +1,38 @@
 package screenshots
 
 import (
-	"bufio"
 	"errors"
 	"fmt"
-	"os"
+	"strings"
 
 	"wbx-script/queryVisualizer/config"
 	"wbx-script/queryVisualizer/customerror"
+	"wbx-script/searchType/atomicfile"
 	"wbx-script/searchType/logger"
 
 	"github.com/playwright-community/playwright-go"
 )
 
-const screenshotFileSuffix = "_screenshot.jpg"
+const (
+	screenshotFileSuffix = "_screenshot.jpg"
+	htmlFileSuffix       = "_page.html"
+	harFileSuffix        = "_network.har"
+	consoleFileSuffix    = "_console.log"
+)
+
+// ScreenshotFilePath returns path of screenshot which is made by MakeScreenshot for prefix
+func ScreenshotFilePath(prefixFilePath string) string {
+	return prefixFilePath + screenshotFileSuffix
+}
+
+type page struct {
+	playwright.Page
+	console *consoleLog
+}
 
 type ScreenshotMaker struct {
 	cfg                *config.ScreenshotMakerConfig
-	pagePool           chan playwright.Page
+	pagePool           chan *page
 	playwrightInstance *playwright.Playwright
 	browser            playwright.Browser
 }
@@ -38,7 +53,7 @@ func NewScreenshotMaker(cfg *config.ScreenshotMakerConfig) (maker *ScreenshotMak
 	maker = &ScreenshotMaker{
 		cfg:                cfg,
 		playwrightInstance: pw,
-		pagePool:           make(chan playwright.Page, cfg.PoolSize),
+		pagePool:           make(chan *page, cfg.PoolSize),
 	}
 
 	if err := maker.launchBrowser(); err != nil {
@@ -46,7 +61,7 @@ func NewScreenshotMaker(cfg *config.ScreenshotMakerConfig) (maker *ScreenshotMak
 	}
 
 	for range cap(maker.pagePool) {
-		page, err := maker.getPage()
+		page, err := maker.getPage(maker.pageOptions())
 
 		if err != nil {
 			return nil, err
@@ -76,55 +91,74 @@ func (s *ScreenshotMaker) Stop() error {
 	return err
 }
 
-func (*ScreenshotMaker) writeScreenshot(prefixFilePath string, screenshot []byte) (err error) {
-	var file *os.File
+// ArtifactFilePaths returns paths of screenshot and debug artifacts enabled in config,
+// all of them are written by successful MakeScreenshot for prefix
+func (s *ScreenshotMaker) ArtifactFilePaths(prefixFilePath string) []string {
+	paths := []string{ScreenshotFilePath(prefixFilePath)}
 
-	if file, err = os.OpenFile(prefixFilePath+screenshotFileSuffix, os.O_WRONLY|os.O_TRUNC|os.O_CREATE, os.ModePerm); err != nil {
-		return err
+	if s.cfg.SaveHTML {
+		paths = append(paths, prefixFilePath+htmlFileSuffix)
 	}
 
-	defer func() {
-		err = errors.Join(file.Close(), err)
-	}()
+	if s.cfg.SaveHAR {
+		paths = append(paths, prefixFilePath+harFileSuffix)
+	}
 
-	writer := bufio.NewWriter(file)
-	defer func() {
-		err = errors.Join(writer.Flush(), err)
-	}()
+	if s.cfg.SaveConsole {
+		paths = append(paths, prefixFilePath+consoleFileSuffix)
+	}
 
-	_, err = writer.Write(screenshot)
+	return paths
+}
 
-	return err
+func (*ScreenshotMaker) writeArtifact(filePath string, data []byte) error {
+	return atomicfile.WriteFile(filePath, data)
 }
 
 func (s *ScreenshotMaker) MakeScreenshot(presetsList, prefixFilePath string) (rerr error) {
-	page := <-s.pagePool
+	pooledPage := <-s.pagePool
 	defer func() {
 		if rerr != nil {
 			rerr = customerror.ErrWrapStack("MakeScreenshot", rerr)
 		}
 
 		logger.Info(fmt.Sprintf("Screenshot file %q has been finished", prefixFilePath))
-		s.pagePool <- page
+		s.pagePool <- pooledPage
 	}()
 
-	timeout := float64(s.cfg.Timeout.Milliseconds())
+	currentPage := pooledPage
 
-	if _, err := page.Goto(
-		s.cfg.VisualizerURL.String(),
-		playwright.PageGotoOptions{
-			Timeout: &timeout,
-		}); err != nil {
-		return err
-	}
+	if s.cfg.SaveHAR {
+		options := s.pageOptions()
+		options.RecordHarPath = playwright.String(prefixFilePath + harFileSuffix)
 
-	if err := page.Locator("textarea").First().Fill(presetsList, playwright.LocatorFillOptions{
-		Timeout: &timeout,
-	}); err != nil {
-		return err
+		recordingPage, err := s.getPage(options)
+
+		if err != nil {
+			return err
+		}
+
+		// HAR file is written by playwright on page closing
+		defer func() {
+			rerr = errors.Join(rerr, recordingPage.Close())
+		}()
+
+		currentPage = recordingPage
 	}
 
-	if err := page.Locator("button").First().Click(); err != nil {
+	currentPage.console.reset()
+
+	defer func() {
+		rerr = errors.Join(rerr, s.saveDebugArtifacts(currentPage, prefixFilePath))
+	}()
+
+	return s.makeScreenshot(currentPage, presetsList, prefixFilePath)
+}
+
+func (s *ScreenshotMaker) makeScreenshot(page *page, presetsList, prefixFilePath string) error {
+	timeout := float64(s.cfg.Timeout.Milliseconds())
+
+	if err := s.loadPresets(page, presetsList, &timeout); err != nil {
 		return err
 	}
 
@@ -141,21 +175,97 @@ func (s *ScreenshotMaker) MakeScreenshot(presetsList, prefixFilePath string) (re
 		return err
 	}
 
-	return s.writeScreenshot(prefixFilePath, screenshot)
+	return s.writeArtifact(ScreenshotFilePath(prefixFilePath), screenshot)
 }
 
-func (s *ScreenshotMaker) getPage() (playwright.Page, error) {
-	page, err := s.browser.NewPage()
+func (s *ScreenshotMaker) saveDebugArtifacts(page *page, prefixFilePath string) error {
+	var err error
+
+	if s.cfg.SaveHTML {
+		content, errContent := page.Content()
+
+		if errContent == nil {
+			errContent = s.writeArtifact(prefixFilePath+htmlFileSuffix, []byte(content))
+		}
+
+		err = errors.Join(err, errContent)
+	}
+
+	if s.cfg.SaveConsole {
+		err = errors.Join(err, s.writeArtifact(prefixFilePath+consoleFileSuffix, page.console.drain()))
+	}
+
+	return err
+}
+
+func (s *ScreenshotMaker) loadPresets(page *page, presetsList string, timeout *float64) error {
+	if s.cfg.DirectURL {
+		_, err := page.Goto(
+			strings.ReplaceAll(s.cfg.VisualizerURLTemplate, config.CardsIDsPlaceholder, presetsList),
+			playwright.PageGotoOptions{
+				Timeout: timeout,
+			})
+
+		return err
+	}
+
+	if _, err := page.Goto(
+		s.cfg.VisualizerURL.String(),
+		playwright.PageGotoOptions{
+			Timeout: timeout,
+		}); err != nil {
+		return err
+	}
+
+	if err := page.Locator("textarea").First().Fill(presetsList, playwright.LocatorFillOptions{
+		Timeout: timeout,
+	}); err != nil {
+		return err
+	}
+
+	return page.Locator("button").First().Click()
+}
+
+func (s *ScreenshotMaker) pageOptions() playwright.BrowserNewPageOptions {
+	options := playwright.BrowserNewPageOptions{}
+
+	if s.cfg.Locale != "" {
+		options.Locale = playwright.String(s.cfg.Locale)
+	}
+
+	if s.cfg.TimezoneID != "" {
+		options.TimezoneId = playwright.String(s.cfg.TimezoneID)
+	}
+
+	return options
+}
+
+func (s *ScreenshotMaker) getPage(options playwright.BrowserNewPageOptions) (*page, error) {
+	browserPage, err := s.browser.NewPage(options)
 
 	if err != nil {
 		return nil, err
 	}
 
-	if err := page.SetViewportSize(s.cfg.Width, s.cfg.Height); err != nil {
-		return nil, err
+	if err := browserPage.SetViewportSize(s.cfg.Width, s.cfg.Height); err != nil {
+		return nil, errors.Join(browserPage.Close(), err)
+	}
+
+	p := &page{
+		Page:    browserPage,
+		console: &consoleLog{},
 	}
 
-	return page, nil
+	if s.cfg.SaveConsole {
+		browserPage.OnConsole(func(msg playwright.ConsoleMessage) {
+			p.console.add(fmt.Sprintf("[%s] %s", msg.Type(), msg.Text()))
+		})
+		browserPage.OnPageError(func(err error) {
+			p.console.add("[pageerror] " + err.Error())
+		})
+	}
+
+	return p, nil
 }
 
 func (s *ScreenshotMaker) launchBrowser() error {
@@ -167,7 +277,44 @@ func (s *ScreenshotMaker) launchBrowser() error {
 
 	var err error
 
-	s.browser, err = s.playwrightInstance.Chromium.Launch()
+	s.browser, err = s.browserType().Launch(s.launchOptions())
 
 	return err
 }
+
+func (s *ScreenshotMaker) browserType() playwright.BrowserType {
+	switch s.cfg.Browser {
+	case config.BrowserFirefox:
+		return s.playwrightInstance.Firefox
+	case config.BrowserWebkit:
+		return s.playwrightInstance.WebKit
+	default:
+		return s.playwrightInstance.Chromium
+	}
+}
+
+func (s *ScreenshotMaker) launchOptions() playwright.BrowserTypeLaunchOptions {
+	options := playwright.BrowserTypeLaunchOptions{
+		Args: s.cfg.BrowserArgs,
+	}
+
+	if s.cfg.BrowserExecutablePath != "" {
+		options.ExecutablePath = playwright.String(s.cfg.BrowserExecutablePath)
+	}
+
+	if proxyURL := s.cfg.BrowserProxy; proxyURL != nil {
+		options.Proxy = &playwright.Proxy{
+			Server: proxyURL.Scheme + "://" + proxyURL.Host,
+		}
+
+		if proxyURL.User != nil {
+			options.Proxy.Username = playwright.String(proxyURL.User.Username())
+
+			if password, ok := proxyURL.User.Password(); ok {
+				options.Proxy.Password = playwright.String(password)
+			}
+		}
+	}
+
+	return options
+}
@@ -0,0 +1,12 @@
+package server
+
+import (
+	"errors"
+)
+
+var (
+	ErrEmptyRequest = errors.New("request should contain text and query")
+	ErrQueueFull    = errors.New("queue of visualize jobs is full, try later")
+	ErrNoJob        = errors.New("there is no job with this id")
+	ErrNoScreenshot = errors.New("job has no screenshot")
+)
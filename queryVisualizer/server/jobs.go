@@ -0,0 +1,142 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+
+	"wbx-script/queryVisualizer/executor"
+	"wbx-script/queryVisualizer/reader"
+)
+
+const (
+	statusQueued  = "queued"
+	statusRunning = "running"
+	statusDone    = "done"
+	statusFailed  = "failed"
+)
+
+// maxFinishedJobs limits memory of long running service, the oldest finished jobs are forgotten
+const maxFinishedJobs = 1000
+
+const jobIDBytes = 8
+
+type job struct {
+	ID         string     `json:"id"`
+	Status     string     `json:"status"`
+	Text       string     `json:"text"`
+	Query      string     `json:"query"`
+	Slug       string     `json:"slug,omitempty"`
+	CardsIDs   []string   `json:"cards_ids,omitempty"`
+	Screenshot string     `json:"screenshot_url,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+
+	line           reader.Line
+	screenshotPath string
+	done           chan struct{}
+}
+
+func newJob(text, query string) (*job, error) {
+	id := make([]byte, jobIDBytes)
+
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	return &job{
+		ID:        hex.EncodeToString(id),
+		Status:    statusQueued,
+		Text:      text,
+		Query:     query,
+		CreatedAt: time.Now(),
+		line:      reader.Line{Text: text, Query: query},
+		done:      make(chan struct{}),
+	}, nil
+}
+
+type jobs struct {
+	mu       *sync.Mutex
+	byID     map[string]*job
+	finished []string
+}
+
+func newJobs() *jobs {
+	return &jobs{
+		mu:   &sync.Mutex{},
+		byID: make(map[string]*job),
+	}
+}
+
+func (j *jobs) add(newJob *job) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.byID[newJob.ID] = newJob
+}
+
+func (j *jobs) remove(id string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	delete(j.byID, id)
+}
+
+// get returns copy of job to be encoded without data race with worker
+func (j *jobs) get(id string) (job, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	found, ok := j.byID[id]
+
+	if !ok {
+		return job{}, false
+	}
+
+	return *found, true
+}
+
+func (j *jobs) start(id string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.byID[id].Status = statusRunning
+}
+
+func (j *jobs) finish(id string, visualization executor.Visualization, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	finished := j.byID[id]
+	finishedAt := time.Now()
+	finished.FinishedAt = &finishedAt
+	finished.Slug = visualization.Slug
+	finished.screenshotPath = visualization.ScreenshotPath
+
+	if visualization.CardsIDs != "" {
+		finished.CardsIDs = strings.Split(visualization.CardsIDs, comma)
+	}
+
+	if visualization.ScreenshotPath != "" {
+		finished.Screenshot = "/jobs/" + id + "/screenshot"
+	}
+
+	finished.Status = statusDone
+
+	if err != nil {
+		finished.Status = statusFailed
+		finished.Error = err.Error()
+	}
+
+	close(finished.done)
+
+	j.finished = append(j.finished, id)
+
+	if len(j.finished) > maxFinishedJobs {
+		delete(j.byID, j.finished[0])
+		j.finished = j.finished[1:]
+	}
+}
@@ -0,0 +1,206 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"wbx-script/queryVisualizer/config"
+	"wbx-script/queryVisualizer/executor"
+	"wbx-script/searchType/logger"
+)
+
+const (
+	shutdownTimeout   = 10 * time.Second
+	readHeaderTimeout = 10 * time.Second
+	maxBodySize       = 1 << 20
+)
+
+const comma = ","
+
+type visualizeRequest struct {
+	Text  string `json:"text"`
+	Query string `json:"query"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Server makes screenshots on demand, jobs are processed by workers from bounded queue
+type Server struct {
+	cfg        *config.Config
+	executor   *executor.Executor
+	jobs       *jobs
+	queue      chan *job
+	httpServer *http.Server
+}
+
+func NewServer(cfg *config.Config, exec *executor.Executor) *Server {
+	s := &Server{
+		cfg:      cfg,
+		executor: exec,
+		jobs:     newJobs(),
+		queue:    make(chan *job, cfg.QueueSize),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /visualize", s.handleVisualize)
+	mux.HandleFunc("GET /jobs/{id}", s.handleStatus)
+	mux.HandleFunc("GET /jobs/{id}/screenshot", s.handleScreenshot)
+
+	s.httpServer = &http.Server{
+		Addr:              cfg.ListenAddress,
+		Handler:           mux,
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+
+	return s
+}
+
+func (s *Server) Run(ctx context.Context) error {
+	defer s.executor.Stop()
+
+	workersCtx, workersStop := context.WithCancel(context.Background())
+	workers := &sync.WaitGroup{}
+
+	// number of workers is equal to page pool, screenshots cannot be made faster
+	for range s.cfg.PoolSize {
+		workers.Add(1)
+
+		go func() {
+			defer workers.Done()
+			s.work(workersCtx)
+		}()
+	}
+
+	defer func() {
+		workersStop()
+		workers.Wait()
+	}()
+
+	errServe := make(chan error, 1)
+
+	go func() {
+		errServe <- s.httpServer.ListenAndServe()
+	}()
+
+	logger.Info("Server is listening on " + s.cfg.ListenAddress)
+
+	select {
+	case err := <-errServe:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	err := s.httpServer.Shutdown(shutdownCtx)
+
+	logger.Info("Server has finished")
+
+	return err
+}
+
+func (s *Server) work(ctx context.Context) {
+	for {
+		select {
+		case queued := <-s.queue:
+			s.jobs.start(queued.ID)
+
+			visualization, err := s.executor.Visualize(ctx, queued.line)
+
+			if err != nil {
+				logger.Error(err.Error())
+			}
+
+			s.jobs.finish(queued.ID, visualization, err)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *Server) handleVisualize(w http.ResponseWriter, r *http.Request) {
+	request := visualizeRequest{}
+
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize)).Decode(&request); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{err.Error()})
+		return
+	}
+
+	if request.Text == "" || request.Query == "" {
+		writeJSON(w, http.StatusBadRequest, errorResponse{ErrEmptyRequest.Error()})
+		return
+	}
+
+	newJob, err := newJob(request.Text, request.Query)
+
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{err.Error()})
+		return
+	}
+
+	s.jobs.add(newJob)
+
+	select {
+	case s.queue <- newJob:
+	default:
+		s.jobs.remove(newJob.ID)
+		writeJSON(w, http.StatusServiceUnavailable, errorResponse{ErrQueueFull.Error()})
+
+		return
+	}
+
+	timer := time.NewTimer(s.cfg.VisualizeWait)
+	defer timer.Stop()
+
+	select {
+	case <-newJob.done:
+		s.writeJob(w, newJob.ID, http.StatusOK)
+	case <-timer.C:
+		w.Header().Set("Location", "/jobs/"+newJob.ID)
+		s.writeJob(w, newJob.ID, http.StatusAccepted)
+	case <-r.Context().Done():
+	}
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	s.writeJob(w, r.PathValue("id"), http.StatusOK)
+}
+
+func (s *Server) handleScreenshot(w http.ResponseWriter, r *http.Request) {
+	found, ok := s.jobs.get(r.PathValue("id"))
+
+	switch {
+	case !ok:
+		writeJSON(w, http.StatusNotFound, errorResponse{ErrNoJob.Error()})
+	case found.screenshotPath == "":
+		writeJSON(w, http.StatusNotFound, errorResponse{ErrNoScreenshot.Error()})
+	default:
+		http.ServeFile(w, r, found.screenshotPath)
+	}
+}
+
+func (s *Server) writeJob(w http.ResponseWriter, id string, status int) {
+	found, ok := s.jobs.get(id)
+
+	if !ok {
+		writeJSON(w, http.StatusNotFound, errorResponse{ErrNoJob.Error()})
+		return
+	}
+
+	writeJSON(w, status, found)
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		logger.Error("Response hasn't been written: " + err.Error())
+	}
+}
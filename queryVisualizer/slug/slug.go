@@ -0,0 +1,62 @@
+package slug
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"strings"
+	"unicode"
+)
+
+const (
+	maxBaseLength = 64
+	hashLength    = 8
+	dash          = '-'
+)
+
+var cyrillicToLatin = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+	'я': "ya",
+}
+
+// Make returns name which is safe to use as a single directory on any filesystem,
+// hash suffix keeps names of different texts distinct after transliteration
+func Make(text string) string {
+	builder := strings.Builder{}
+
+	for _, r := range strings.ToLower(text) {
+		latin, isCyrillic := cyrillicToLatin[r]
+
+		switch {
+		case isCyrillic:
+			builder.WriteString(latin)
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			builder.WriteRune(r)
+		default:
+			builder.WriteRune(dash)
+		}
+	}
+
+	base := collapseDashes(builder.String())
+
+	if len(base) > maxBaseLength {
+		base = strings.TrimRight(base[:maxBaseLength], string(dash))
+	}
+
+	hash := sha1.Sum([]byte(text))
+	suffix := hex.EncodeToString(hash[:])[:hashLength]
+
+	if base == "" {
+		return suffix
+	}
+
+	return base + string(dash) + suffix
+}
+
+func collapseDashes(text string) string {
+	parts := strings.FieldsFunc(text, func(r rune) bool { return r == dash })
+
+	return strings.Join(parts, string(dash))
+}
@@ -0,0 +1,78 @@
+package atomicfile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+const filePerm = 0o644
+
+// File is written to temporary file in the same directory and renamed to its path only by Commit,
+// so interrupted runs never leave half-written files which look valid
+type File struct {
+	*os.File
+	path   string
+	closed bool
+}
+
+func Create(path string) (*File, error) {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = file.Chmod(filePerm); err != nil {
+		return nil, errors.Join(err, file.Close(), os.Remove(file.Name()))
+	}
+
+	return &File{File: file, path: path}, nil
+}
+
+func (f *File) Commit() error {
+	if f.closed {
+		return os.ErrClosed
+	}
+
+	f.closed = true
+
+	if err := f.File.Sync(); err != nil {
+		return errors.Join(err, f.File.Close(), os.Remove(f.File.Name()))
+	}
+
+	if err := f.File.Close(); err != nil {
+		return errors.Join(err, os.Remove(f.File.Name()))
+	}
+
+	return os.Rename(f.File.Name(), f.path)
+}
+
+// Close discards written data if file hasn't been committed
+func (f *File) Close() error {
+	if f.closed {
+		return nil
+	}
+
+	f.closed = true
+
+	return errors.Join(f.File.Close(), os.Remove(f.File.Name()))
+}
+
+func WriteFile(path string, data []byte) (err error) {
+	file, err := Create(path)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = errors.Join(err, file.Close())
+	}()
+
+	if _, err = file.Write(data); err != nil {
+		return err
+	}
+
+	return file.Commit()
+}
@@ -0,0 +1,193 @@
+package balancer
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"wbx-script/searchType/logger"
+)
+
+const (
+	StrategyRoundRobin    = "round-robin"
+	StrategyLeastInflight = "least-inflight"
+)
+
+const (
+	DefaultMaxFails = 3
+	DefaultEjectFor = 30 * time.Second
+	DefaultFailover = 1
+)
+
+const comma = ","
+
+type Config struct {
+	Strategy string
+	// consecutive failures of endpoint after which it is ejected
+	MaxFails int
+	EjectFor time.Duration
+	// number of other endpoints tried after failure of the first one
+	Failover int
+}
+
+func (c Config) Validate() error {
+	var errSum error
+
+	switch c.Strategy {
+	case StrategyRoundRobin, StrategyLeastInflight:
+	default:
+		errSum = errors.Join(errSum, ErrStrategy)
+	}
+
+	if c.MaxFails <= 0 {
+		errSum = errors.Join(errSum, ErrMaxFails)
+	}
+
+	if c.EjectFor <= 0 {
+		errSum = errors.Join(errSum, ErrEjectFor)
+	}
+
+	if c.Failover < 0 {
+		errSum = errors.Join(errSum, ErrFailover)
+	}
+
+	return errSum
+}
+
+// SplitEndpoints splits comma separated list of endpoints, empty items are skipped
+func SplitEndpoints(value string) []string {
+	var endpoints []string
+
+	for _, endpoint := range strings.Split(value, comma) {
+		if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+
+	return endpoints
+}
+
+type endpoint struct {
+	url          *url.URL
+	inflight     int
+	fails        int
+	ejectedUntil time.Time
+}
+
+// Balancer spreads requests over replicas of service and tracks their health passively by results of requests
+type Balancer struct {
+	cfg       Config
+	mu        *sync.Mutex
+	endpoints []*endpoint
+	next      int
+}
+
+// New creates balancer of endpoints, list of endpoints is checked to be non-empty by configs
+func New(urls []*url.URL, cfg Config) *Balancer {
+	endpoints := make([]*endpoint, 0, len(urls))
+
+	for _, u := range urls {
+		endpoints = append(endpoints, &endpoint{url: u})
+	}
+
+	return &Balancer{cfg: cfg, mu: &sync.Mutex{}, endpoints: endpoints}
+}
+
+// Primary returns first endpoint, it is used for keys which shouldn't depend on chosen replica
+func (b *Balancer) Primary() *url.URL {
+	return b.endpoints[0].url
+}
+
+// Do calls request with chosen endpoint and retries it on other endpoints while error is ErrUnavailable
+func (b *Balancer) Do(ctx context.Context, request func(endpoint *url.URL) error) error {
+	var errSum error
+
+	tried := make(map[*endpoint]bool, len(b.endpoints))
+
+	for attempt := 0; attempt <= b.cfg.Failover && attempt < len(b.endpoints); attempt++ {
+		chosen := b.acquire(tried)
+		err := request(chosen.url)
+		b.release(chosen, err)
+
+		if err == nil || !errors.Is(err, ErrUnavailable) {
+			return err
+		}
+
+		errSum = errors.Join(errSum, err)
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	return errSum
+}
+
+func (b *Balancer) acquire(tried map[*endpoint]bool) *endpoint {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	candidates := make([]*endpoint, 0, len(b.endpoints))
+
+	for i := range b.endpoints {
+		e := b.endpoints[(b.next+i)%len(b.endpoints)]
+
+		if !tried[e] && !now.Before(e.ejectedUntil) {
+			candidates = append(candidates, e)
+		}
+	}
+
+	// all untried endpoints are ejected, the one which is going to come back first is used
+	if len(candidates) == 0 {
+		for _, e := range b.endpoints {
+			if !tried[e] && (len(candidates) == 0 || e.ejectedUntil.Before(candidates[0].ejectedUntil)) {
+				candidates = []*endpoint{e}
+			}
+		}
+	}
+
+	chosen := candidates[0]
+
+	if b.cfg.Strategy == StrategyLeastInflight {
+		for _, e := range candidates[1:] {
+			if e.inflight < chosen.inflight {
+				chosen = e
+			}
+		}
+	}
+
+	for i, e := range b.endpoints {
+		if e == chosen {
+			b.next = (i + 1) % len(b.endpoints)
+		}
+	}
+
+	chosen.inflight++
+	tried[chosen] = true
+
+	return chosen
+}
+
+func (b *Balancer) release(e *endpoint, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	e.inflight--
+
+	if !errors.Is(err, ErrUnavailable) {
+		e.fails = 0
+		return
+	}
+
+	e.fails++
+
+	if e.fails >= b.cfg.MaxFails {
+		e.fails = 0
+		e.ejectedUntil = time.Now().Add(b.cfg.EjectFor)
+		logger.Error("Endpoint is ejected", "endpoint", e.url.Redacted(), "until", e.ejectedUntil)
+	}
+}
@@ -0,0 +1,168 @@
+package balancer
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+)
+
+var errRequest = errors.New("request error")
+
+func newTestBalancer(cfg Config, hosts ...string) *Balancer {
+	urls := make([]*url.URL, 0, len(hosts))
+
+	for _, host := range hosts {
+		urls = append(urls, &url.URL{Scheme: "http", Host: host})
+	}
+
+	return New(urls, cfg)
+}
+
+func TestDo(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		// unavailable endpoints fail every request with ErrUnavailable
+		unavailable []string
+		calls       int
+		// want is order of endpoints which have got requests
+		want     []string
+		wantErrs int
+	}{
+		{
+			name:  "round-robin order",
+			cfg:   Config{Strategy: StrategyRoundRobin, MaxFails: 3, EjectFor: time.Minute, Failover: 1},
+			calls: 4,
+			want:  []string{"a", "b", "c", "a"},
+		},
+		{
+			name:        "failover to next endpoint",
+			cfg:         Config{Strategy: StrategyRoundRobin, MaxFails: 3, EjectFor: time.Minute, Failover: 1},
+			unavailable: []string{"b"},
+			calls:       2,
+			want:        []string{"a", "b", "c"},
+		},
+		{
+			name:        "ejection after max fails",
+			cfg:         Config{Strategy: StrategyRoundRobin, MaxFails: 1, EjectFor: time.Minute, Failover: 1},
+			unavailable: []string{"a"},
+			calls:       3,
+			want:        []string{"a", "b", "c", "b"},
+		},
+		{
+			name:        "no ejection before max fails",
+			cfg:         Config{Strategy: StrategyRoundRobin, MaxFails: 2, EjectFor: time.Minute, Failover: 0},
+			unavailable: []string{"a"},
+			calls:       4,
+			want:        []string{"a", "b", "c", "a"},
+			wantErrs:    2,
+		},
+		{
+			name:        "failover skips ejected endpoints",
+			cfg:         Config{Strategy: StrategyRoundRobin, MaxFails: 1, EjectFor: time.Minute, Failover: 2},
+			unavailable: []string{"a", "b"},
+			calls:       2,
+			want:        []string{"a", "b", "c", "c"},
+		},
+		{
+			name:        "failover is limited",
+			cfg:         Config{Strategy: StrategyRoundRobin, MaxFails: 3, EjectFor: time.Minute, Failover: 0},
+			unavailable: []string{"a"},
+			calls:       1,
+			want:        []string{"a"},
+			wantErrs:    1,
+		},
+		{
+			name:        "all endpoints are ejected",
+			cfg:         Config{Strategy: StrategyRoundRobin, MaxFails: 1, EjectFor: time.Minute, Failover: 2},
+			unavailable: []string{"a", "b", "c"},
+			calls:       2,
+			want:        []string{"a", "b", "c", "a", "b", "c"},
+			wantErrs:    2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBalancer(tt.cfg, "a", "b", "c")
+
+			var (
+				got  []string
+				errs int
+			)
+
+			for range tt.calls {
+				err := b.Do(context.Background(), func(endpoint *url.URL) error {
+					got = append(got, endpoint.Host)
+
+					if slices.Contains(tt.unavailable, endpoint.Host) {
+						return ErrWrapUnavailable(errRequest)
+					}
+
+					return nil
+				})
+
+				if err != nil {
+					errs++
+				}
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("requested endpoints = %v, want %v", got, tt.want)
+			}
+
+			if errs != tt.wantErrs {
+				t.Errorf("failed calls = %d, want %d", errs, tt.wantErrs)
+			}
+		})
+	}
+}
+
+func TestDoWithoutFailover(t *testing.T) {
+	b := newTestBalancer(Config{Strategy: StrategyRoundRobin, MaxFails: 1, EjectFor: time.Minute, Failover: 2}, "a", "b")
+	requests := 0
+
+	err := b.Do(context.Background(), func(*url.URL) error {
+		requests++
+		return errRequest
+	})
+
+	if !errors.Is(err, errRequest) || requests != 1 {
+		t.Errorf("error = %v after %d requests, want request error after 1 request", err, requests)
+	}
+
+	// endpoint which has answered with error isn't ejected
+	if ejectedUntil := b.endpoints[0].ejectedUntil; !ejectedUntil.IsZero() {
+		t.Errorf("endpoint is ejected until %v", ejectedUntil)
+	}
+}
+
+func TestLeastInflight(t *testing.T) {
+	b := newTestBalancer(Config{Strategy: StrategyLeastInflight, MaxFails: 3, EjectFor: time.Minute}, "a", "b", "c")
+
+	acquire := func() *endpoint {
+		return b.acquire(make(map[*endpoint]bool))
+	}
+
+	a, second, third := acquire(), acquire(), acquire()
+
+	if got := []string{a.url.Host, second.url.Host, third.url.Host}; !slices.Equal(got, []string{"a", "b", "c"}) {
+		t.Fatalf("endpoints without requests = %v, want [a b c]", got)
+	}
+
+	b.release(second, nil)
+
+	if got := acquire(); got != second {
+		t.Errorf("least inflight endpoint = %s, want b", got.url.Host)
+	}
+
+	b.release(a, nil)
+	b.release(third, nil)
+
+	if got := acquire(); got != a && got != third {
+		t.Errorf("least inflight endpoint = %s, want a or c", got.url.Host)
+	}
+}
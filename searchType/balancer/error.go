@@ -0,0 +1,19 @@
+package balancer
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrStrategy    = errors.New("balance strategy should be one of round-robin, least-inflight")
+	ErrMaxFails    = errors.New("max fails of endpoint cannot be less than zero or equal")
+	ErrEjectFor    = errors.New("eject duration of endpoint cannot be less than zero or equal")
+	ErrFailover    = errors.New("failover retries cannot be less than zero")
+	ErrUnavailable = errors.New("endpoint is unavailable")
+)
+
+// ErrWrapUnavailable marks error of request as failure of endpoint, only such errors lead to failover
+func ErrWrapUnavailable(err error) error {
+	return fmt.Errorf("%w: %w", ErrUnavailable, err)
+}
@@ -0,0 +1,159 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"wbx-script/searchType/atomicfile"
+	"wbx-script/searchType/logger"
+)
+
+const (
+	DefaultTTL = 24 * time.Hour
+	// shardLength is length of key prefix used as subfolder to not keep all entries in one folder
+	shardLength = 2
+)
+
+// Cache keeps successful response bodies on disk by normalized request URL,
+// nil cache is valid and always misses
+type Cache struct {
+	dir    string
+	ttl    time.Duration
+	hits   *atomic.Int64
+	misses *atomic.Int64
+}
+
+// New returns nil cache if dir is empty, expired entries are removed on start
+func New(dir string, ttl time.Duration) (*Cache, error) {
+	if dir == "" {
+		return nil, nil
+	}
+
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	c := &Cache{
+		dir:    dir,
+		ttl:    ttl,
+		hits:   &atomic.Int64{},
+		misses: &atomic.Int64{},
+	}
+
+	// failed pruning doesn't fail run, the rest of expired entries is removed next time
+	if err := c.prune(); err != nil {
+		logger.Error(err.Error())
+	}
+
+	return c, nil
+}
+
+func (c *Cache) prune() error {
+	return filepath.WalkDir(c.dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		info, err := entry.Info()
+
+		if err != nil {
+			return err
+		}
+
+		if c.isExpired(info) {
+			return os.Remove(path)
+		}
+
+		return nil
+	})
+}
+
+func (c *Cache) isExpired(info fs.FileInfo) bool {
+	return time.Since(info.ModTime()) > c.ttl
+}
+
+// normalize makes equal requests with another order of query params or case of host the same key
+func normalize(requestURL string) string {
+	u, err := url.Parse(requestURL)
+
+	if err != nil {
+		return requestURL
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment = ""
+	u.RawQuery = u.Query().Encode()
+
+	return u.String()
+}
+
+func (c *Cache) path(requestURL string) string {
+	hash := sha256.Sum256([]byte(normalize(requestURL)))
+	key := hex.EncodeToString(hash[:])
+
+	return filepath.Join(c.dir, key[:shardLength], key)
+}
+
+func (c *Cache) Get(requestURL string) ([]byte, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	path := c.path(requestURL)
+	info, err := os.Stat(path)
+
+	if err != nil {
+		c.misses.Add(1)
+		return nil, false
+	}
+
+	if c.isExpired(info) {
+		c.misses.Add(1)
+		// entry is written again by Put of the following request, so error of removal is ignored
+		_ = os.Remove(path)
+
+		return nil, false
+	}
+
+	body, err := os.ReadFile(path)
+
+	if err != nil {
+		c.misses.Add(1)
+		return nil, false
+	}
+
+	c.hits.Add(1)
+
+	return body, true
+}
+
+func (c *Cache) Put(requestURL string, body []byte) error {
+	if c == nil {
+		return nil
+	}
+
+	path := c.path(requestURL)
+
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+
+	return atomicfile.WriteFile(path, body)
+}
+
+// LogSummary prints counters of cache usage
+func (c *Cache) LogSummary() {
+	if c == nil {
+		return
+	}
+
+	logger.Info("Cache summary", "hits", c.hits.Load(), "misses", c.misses.Load())
+}
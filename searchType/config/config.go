@@ -5,8 +5,15 @@ import (
 	"flag"
 	"net/url"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
+
+	"wbx-script/searchType/balancer"
+	"wbx-script/searchType/cache"
+	"wbx-script/searchType/notify"
+	"wbx-script/searchType/sampling"
 )
 
 const (
@@ -16,8 +23,47 @@ const (
 	defaultRps              = 1
 	defaultCsvSeparator     = "\t"
 	defaultPresetsSeparator = ","
+	defaultQueryColumn      = "query"
+	defaultQueryField       = "query"
+	defaultTopN             = 10
+	defaultListenAddress    = ":8080"
+	defaultMaxBatchSize     = 100
+)
+
+const StdinPath = "-"
+
+// ServeCommand is first argument which starts http service instead of batch run
+const ServeCommand = "serve"
+
+const runTimestampLayout = "20060102T150405"
+
+const (
+	FormatLines = "lines"
+	FormatCSV   = "csv"
+	FormatTSV   = "tsv"
+	FormatJSONL = "jsonl"
+)
+
+var extensionToFormat = map[string]string{
+	".txt":    FormatLines,
+	".csv":    FormatCSV,
+	".tsv":    FormatTSV,
+	".jsonl":  FormatJSONL,
+	".ndjson": FormatJSONL,
+}
+
+var compressionExtensions = []string{".gz", ".zst", ".zstd"}
+
+const (
+	NormalizeTrim   = "trim"
+	NormalizeLower  = "lower"
+	NormalizeSpaces = "spaces"
+	NormalizeNFKC   = "nfkc"
+	NormalizeYo     = "yo"
 )
 
+const comma = ","
+
 func parseEnvironment() (int, error) {
 	limit := os.Getenv("GO_ERR_GROUP_LIMIT")
 
@@ -42,19 +88,67 @@ type Config struct {
 	QueriesPath       string
 	PresetsSeparator  string
 	CsvSeparator      string
-	ExtendMatchURL    *url.URL
+	ExtendMatchURLs   []*url.URL
+	Balancer          balancer.Config
+	BucketServerURLs  []*url.URL
+	EnrichPresets     bool
 	CountOfRetry      int
 	Timeout           time.Duration
 	LogPeriod         time.Duration
 	GoErrGroupLimiter int
+	InputFormat       string
+	QueryColumn       string
+	QueryField        string
+	NormalizeSteps    []string
+	Dedup             bool
+	WeightColumn      string
+	WeightField       string
+	TopN              int
+	Sampling          sampling.Config
+	OutputDir         string
+	RunName           string
+	Timestamped       bool
+	// OutputPath is OutputDir with optional run subfolder, all results are written there
+	OutputPath    string
+	Serve         bool
+	ListenAddress string
+	MaxBatchSize  int
+	Webhook       notify.Config
+	// CacheDir is empty if cache is disabled
+	CacheDir string
+	CacheTTL time.Duration
 }
 
 func Parse() (*Config, error) {
 	cfg := Config{}
 
-	var ExtendMatchURLStr string
+	var ExtendMatchURLStr, bucketServerURLStr, webhookURLStr, normalizeSteps string
+
+	args := os.Args[1:]
+
+	if len(args) > 0 && args[0] == ServeCommand {
+		cfg.Serve = true
+		args = args[1:]
+	}
 
-	flag.StringVar(&cfg.QueriesPath, "queries-path", "", "Path to list of queries")
+	flag.StringVar(&cfg.QueriesPath, "queries-path", "", "Path to list of queries, '-' to read from stdin")
+	flag.StringVar(
+		&cfg.InputFormat,
+		"input-format",
+		"",
+		"Format of queries file: lines|csv|tsv|jsonl, detected by extension if empty",
+	)
+	flag.StringVar(&cfg.QueryColumn, "query-column", defaultQueryColumn, "Header name of query column for csv/tsv input")
+	flag.StringVar(&cfg.QueryField, "query-field", defaultQueryField, "Dot separated path to query field for jsonl input")
+	flag.StringVar(&cfg.WeightColumn, "weight-column", "", "Header name of optional weight column for csv/tsv input")
+	flag.StringVar(&cfg.WeightField, "weight-field", "", "Dot separated path to optional weight field for jsonl input")
+	flag.StringVar(&cfg.Sampling.Mode, "sample-mode", sampling.ModeNone, "Sampling of queries: first|random|stratified|nth")
+	flag.IntVar(&cfg.Sampling.Size, "sample-size", 0, "Number of sampled queries, step for nth sample mode")
+	flag.Int64Var(&cfg.Sampling.Seed, "sample-seed", 0, "Seed of random and stratified sample modes")
+	flag.StringVar(&cfg.OutputDir, "output-dir", "", "Path for saving result files, directory of queries file by default")
+	flag.StringVar(&cfg.RunName, "run-name", "", "Results are saved to subfolder of output dir with this name")
+	flag.BoolVar(&cfg.Timestamped, "timestamped", false, "Add start time to name of results subfolder")
+	flag.IntVar(&cfg.TopN, "top-n", defaultTopN, "Number of heaviest queries per category in summary report")
 	flag.StringVar(&cfg.PresetsSeparator, "presets-separator", defaultPresetsSeparator, "Separator of presets(writing)")
 	flag.StringVar(
 		&cfg.CsvSeparator,
@@ -62,17 +156,81 @@ func Parse() (*Config, error) {
 		defaultCsvSeparator,
 		"Separator of csv file with text of search and query part of url(writing)",
 	)
-	flag.StringVar(&ExtendMatchURLStr, "extend-match-url", "", "Url of extend match machine")
+	flag.StringVar(
+		&normalizeSteps,
+		"normalize",
+		"",
+		"Comma separated normalization steps applied in order: trim,lower,spaces,nfkc,yo",
+	)
+	flag.BoolVar(&cfg.Dedup, "dedup", false, "Send only unique queries after normalization to extend match server")
+	flag.StringVar(&ExtendMatchURLStr, "extend-match-url", "", "Comma separated urls of extend match machine replicas")
+	flag.StringVar(
+		&cfg.Balancer.Strategy,
+		"balance",
+		balancer.StrategyRoundRobin,
+		"Balancing of requests over extend match and bucket server replicas: round-robin|least-inflight",
+	)
+	flag.IntVar(&cfg.Balancer.MaxFails, "max-fails", balancer.DefaultMaxFails, "Consecutive failures after which replica is ejected")
+	flag.DurationVar(&cfg.Balancer.EjectFor, "eject-for", balancer.DefaultEjectFor, "Time of replica ejection(duration format)")
+	flag.IntVar(&cfg.Balancer.Failover, "failover", balancer.DefaultFailover, "Number of other replicas tried after failed request")
+	flag.StringVar(&bucketServerURLStr, "bucket-server-url", "", "Comma separated urls of bucket server replicas for presets enrichment")
+	flag.BoolVar(
+		&cfg.EnrichPresets,
+		"enrich-presets",
+		false,
+		"Check every collected preset on bucket server and write presets report",
+	)
 	flag.IntVar(&cfg.Rps, "rps", defaultRps, "Request per second")
 	flag.IntVar(&cfg.CountOfRetry, "retry", defaultRetry, "Count of retry per request to extend search server")
 	flag.DurationVar(&cfg.Timeout, "timeout", defaultTimeout, "Timeout of making request to server(duration format)")
 	flag.DurationVar(&cfg.LogPeriod, "logger-period", defaultLogPeriod, "Period of logger's printing(duration format)")
-	flag.Parse()
+	flag.StringVar(&cfg.ListenAddress, "listen", defaultListenAddress, "Address of http service in serve mode")
+	flag.IntVar(&cfg.MaxBatchSize, "max-batch-size", defaultMaxBatchSize, "Max number of queries per request in serve mode")
+
+	var noCache bool
+
+	flag.StringVar(
+		&cfg.CacheDir,
+		"cache-dir",
+		"",
+		"Directory of cached responses of extend match server, caching is disabled if empty",
+	)
+	flag.DurationVar(&cfg.CacheTTL, "cache-ttl", cache.DefaultTTL, "Time to live of cached response(duration format)")
+	flag.BoolVar(&noCache, "no-cache", false, "Send all requests to extend match server without cache")
+	flag.StringVar(&webhookURLStr, "webhook-url", "", "Url for POST of run summary on finish, failure or interruption")
+	flag.StringVar(
+		&cfg.Webhook.TemplatePath,
+		"webhook-template",
+		"",
+		"Path to text/template of webhook body, JSON summary is sent by default",
+	)
+
+	if err := flag.CommandLine.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if noCache {
+		cfg.CacheDir = ""
+	}
+
+	if normalizeSteps != "" {
+		cfg.NormalizeSteps = strings.Split(normalizeSteps, comma)
+	}
+
+	if err := cfg.validate(ExtendMatchURLStr, bucketServerURLStr); err != nil {
+		return nil, err
+	}
 
-	if err := cfg.validate(ExtendMatchURLStr); err != nil {
+	if err := cfg.validateWebhook(webhookURLStr); err != nil {
 		return nil, err
 	}
 
+	if !cfg.Serve {
+		if err := cfg.setupOutputPath(time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
 	var err error
 
 	cfg.GoErrGroupLimiter, err = parseEnvironment()
@@ -102,21 +260,70 @@ func (*Config) checkURL(urlStr string) (*url.URL, error) {
 	return u, nil
 }
 
-func (c *Config) validate(urlStr string) error {
+// checkURLs checks comma separated urls of replicas
+func (c *Config) checkURLs(urlsStr string) ([]*url.URL, error) {
 	var (
-		err, errSum error
-		u           *url.URL
+		urls   []*url.URL
+		errSum error
 	)
 
-	if _, err = os.Stat(c.QueriesPath); err != nil {
+	for _, endpoint := range balancer.SplitEndpoints(urlsStr) {
+		u, err := c.checkURL(endpoint)
+		errSum = errors.Join(errSum, err)
+		urls = append(urls, u)
+	}
+
+	if len(urls) == 0 {
+		return nil, ErrSchema
+	}
+
+	return urls, errSum
+}
+
+func (c *Config) validate(urlStr, bucketURLStr string) error {
+	var err, errSum error
+
+	if !c.Serve && c.QueriesPath != StdinPath {
+		if _, err = os.Stat(c.QueriesPath); err != nil {
+			errSum = errors.Join(errSum, err)
+		}
+	}
+
+	if c.InputFormat == "" {
+		c.InputFormat = detectInputFormat(c.QueriesPath)
+	}
+
+	switch c.InputFormat {
+	case FormatLines, FormatCSV, FormatTSV, FormatJSONL:
+	default:
+		errSum = errors.Join(errSum, ErrInputFormat)
+	}
+
+	if c.QueryColumn == "" || c.QueryField == "" {
+		errSum = errors.Join(errSum, ErrQueryColumn)
+	}
+
+	for _, step := range c.NormalizeSteps {
+		switch step {
+		case NormalizeTrim, NormalizeLower, NormalizeSpaces, NormalizeNFKC, NormalizeYo:
+		default:
+			errSum = errors.Join(errSum, ErrWrapNormalizeStep(step))
+		}
+	}
+
+	if c.ExtendMatchURLs, err = c.checkURLs(urlStr); err != nil {
 		errSum = errors.Join(errSum, err)
 	}
 
-	if u, err = c.checkURL(urlStr); err != nil {
+	if err = c.Balancer.Validate(); err != nil {
 		errSum = errors.Join(errSum, err)
 	}
 
-	c.ExtendMatchURL = u
+	if c.EnrichPresets {
+		if c.BucketServerURLs, err = c.checkURLs(bucketURLStr); err != nil {
+			errSum = errors.Join(errSum, err)
+		}
+	}
 
 	if c.Rps <= 0 {
 		errSum = errors.Join(errSum, ErrRps)
@@ -134,5 +341,94 @@ func (c *Config) validate(urlStr string) error {
 		errSum = errors.Join(errSum, ErrLoggerPeriod)
 	}
 
+	if c.CacheTTL <= 0 {
+		errSum = errors.Join(errSum, ErrCacheTTL)
+	}
+
+	if c.TopN <= 0 {
+		errSum = errors.Join(errSum, ErrTopN)
+	}
+
+	if c.Serve && c.ListenAddress == "" {
+		errSum = errors.Join(errSum, ErrListenAddress)
+	}
+
+	if c.Serve && c.MaxBatchSize <= 0 {
+		errSum = errors.Join(errSum, ErrMaxBatchSize)
+	}
+
+	if err = c.Sampling.Validate(c.WeightColumn != "" || c.WeightField != ""); err != nil {
+		errSum = errors.Join(errSum, err)
+	}
+
 	return errSum
 }
+
+func (c *Config) validateWebhook(webhookURLStr string) error {
+	if webhookURLStr == "" {
+		if c.Webhook.TemplatePath != "" {
+			return ErrWebhookTemplate
+		}
+
+		return nil
+	}
+
+	var err error
+
+	if c.Webhook.URL, err = c.checkURL(webhookURLStr); err != nil {
+		return err
+	}
+
+	if c.Webhook.TemplatePath != "" {
+		if info, errStat := os.Stat(c.Webhook.TemplatePath); errStat != nil || info.IsDir() {
+			return errors.Join(errStat, ErrWebhookTemplatePath)
+		}
+	}
+
+	return nil
+}
+
+func (c *Config) setupOutputPath(startedAt time.Time) error {
+	if c.OutputDir == "" {
+		c.OutputDir = filepath.Dir(c.QueriesPath)
+	}
+
+	if info, err := os.Stat(c.OutputDir); err != nil || !info.IsDir() {
+		return errors.Join(err, ErrOutputDir)
+	}
+
+	subfolder := c.RunName
+
+	if c.Timestamped {
+		subfolder = strings.Trim(subfolder+"_"+startedAt.Format(runTimestampLayout), "_")
+	}
+
+	if subfolder != "" && (filepath.Base(subfolder) != subfolder || subfolder == "." || subfolder == "..") {
+		return ErrRunName
+	}
+
+	c.OutputPath = filepath.Join(c.OutputDir, subfolder)
+
+	return os.MkdirAll(c.OutputPath, os.ModePerm)
+}
+
+func (c *Config) IsNormalizationEnabled() bool {
+	return len(c.NormalizeSteps) > 0 || c.Dedup
+}
+
+func detectInputFormat(path string) string {
+	extension := strings.ToLower(filepath.Ext(path))
+
+	for _, compressionExtension := range compressionExtensions {
+		if extension == compressionExtension {
+			extension = strings.ToLower(filepath.Ext(strings.TrimSuffix(path, filepath.Ext(path))))
+			break
+		}
+	}
+
+	if format, ok := extensionToFormat[extension]; ok {
+		return format
+	}
+
+	return FormatLines
+}
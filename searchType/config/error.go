@@ -2,6 +2,7 @@ package config
 
 import (
 	"errors"
+	"fmt"
 )
 
 type ErrURL error
@@ -17,4 +18,19 @@ var (
 	ErrRetry                = errors.New("retry per request cannot be less than zero")
 	ErrRps                  = errors.New("request per second cannot be less than zero or equal")
 	ErrInvalidateGroupLimit = errors.New("validation hasn't been passed for 'GO_ERR_GROUP_LIMIT' env, cannot be less or equal zero")
+	ErrInputFormat          = errors.New("input format should be one of lines, csv, tsv, jsonl")
+	ErrQueryColumn          = errors.New("query column and query field cannot be empty")
+	ErrOutputDir            = errors.New("output dir should be existing directory")
+	ErrRunName              = errors.New("run name cannot contain path separators")
+	ErrTopN                 = errors.New("top-n cannot be less than zero or equal")
+	ErrNormalizeStep        = errors.New("unknown normalization step, expected trim, lower, spaces, nfkc or yo")
+	ErrListenAddress        = errors.New("listen address cannot be empty in serve mode")
+	ErrMaxBatchSize         = errors.New("max batch size cannot be less than zero or equal")
+	ErrWebhookTemplate      = errors.New("webhook template cannot be used without webhook url")
+	ErrWebhookTemplatePath  = errors.New("webhook template should be existing file")
+	ErrCacheTTL             = errors.New("cache ttl cannot be less than zero or equal")
 )
+
+func ErrWrapNormalizeStep(step string) error {
+	return fmt.Errorf("step - %q: %w", step, ErrNormalizeStep)
+}
@@ -4,31 +4,45 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"net/http"
+	"net/url"
 	"time"
 
+	"wbx-script/searchType/balancer"
+	"wbx-script/searchType/cache"
 	"wbx-script/searchType/config"
 	"wbx-script/searchType/logger"
+	"wbx-script/searchType/reader"
 
 	"github.com/go-resty/resty/v2"
 	"golang.org/x/sync/errgroup"
 )
 
+type Response struct {
+	Query reader.Query
+	Body  []byte
+}
+
 type QueryExecutor struct {
-	queries        <-chan string
-	ResponseBodies chan []byte
+	queries        <-chan reader.Query
+	ResponseBodies chan Response
 	rateLimiter    *time.Ticker
 	client         *resty.Client
 	cfg            *config.Config
+	cache          *cache.Cache
+	balancer       *balancer.Balancer
 }
 
-func NewQueryExecutor(cfg *config.Config, queries <-chan string) *QueryExecutor {
+func NewQueryExecutor(cfg *config.Config, queries <-chan reader.Query, responseCache *cache.Cache) *QueryExecutor {
 	ticker := time.NewTicker(time.Second / time.Duration(cfg.Rps))
 
 	return &QueryExecutor{
 		queries:        queries,
-		ResponseBodies: make(chan []byte),
+		ResponseBodies: make(chan Response),
 		cfg:            cfg,
 		rateLimiter:    ticker,
+		cache:          responseCache,
+		balancer:       balancer.New(cfg.ExtendMatchURLs, cfg.Balancer),
 		client:         resty.New().SetTimeout(cfg.Timeout).SetRetryCount(cfg.CountOfRetry).SetDisableWarn(true),
 	}
 }
@@ -46,6 +60,7 @@ func (w *QueryExecutor) Run(ctx context.Context) (err error) {
 	defer func() {
 		w.rateLimiter.Stop()
 		close(w.ResponseBodies)
+		w.cache.LogSummary()
 		logger.Info("Worker has finished")
 	}()
 
@@ -56,24 +71,101 @@ func (w *QueryExecutor) Run(ctx context.Context) (err error) {
 		err = errors.Join(errGroup.Wait(), err)
 	}()
 
-	q := w.cfg.ExtendMatchURL.Query()
-
 	for query := range w.queries {
+		// cached responses don't wait for rate limiter
+		if body, ok := w.cache.Get(w.cacheKey(query.Text)); ok {
+			errGroup.Go(func() error {
+				return w.send(errGroupCtx, Response{Query: query, Body: body})
+			})
+
+			continue
+		}
+
 		if errLimiter := w.waitLimiterAllowed(errGroupCtx); errLimiter != nil {
 			return errLimiter
 		}
 
-		q.Set("query", query)
-		w.cfg.ExtendMatchURL.RawQuery = q.Encode()
-
 		errGroup.Go(func() error {
-			return w.do(errGroupCtx, w.cfg.ExtendMatchURL.String())
+			return w.do(errGroupCtx, query)
 		})
 	}
 
 	return
 }
 
+// Stop releases rate limiter of executor which is used without Run
+func (w *QueryExecutor) Stop() {
+	w.rateLimiter.Stop()
+}
+
+// cacheKey is built with the first replica, so cached responses are shared by all replicas
+func (w *QueryExecutor) cacheKey(text string) string {
+	return w.requestURL(w.balancer.Primary(), text)
+}
+
+func (*QueryExecutor) requestURL(endpoint *url.URL, text string) string {
+	extendMatchURL := *endpoint
+	q := extendMatchURL.Query()
+	q.Set("query", text)
+	extendMatchURL.RawQuery = q.Encode()
+
+	return extendMatchURL.String()
+}
+
+// Classify makes single request to extend match server with the same rate limiting as Run
+func (w *QueryExecutor) Classify(ctx context.Context, text string) ([]byte, error) {
+	if body, ok := w.cache.Get(w.cacheKey(text)); ok {
+		return body, nil
+	}
+
+	if err := w.waitLimiterAllowed(ctx); err != nil {
+		return nil, err
+	}
+
+	return w.request(ctx, text)
+}
+
+// request sends query to one of extend match replicas and fails over to others if replica is unavailable
+func (w *QueryExecutor) request(ctx context.Context, text string) ([]byte, error) {
+	var body []byte
+
+	err := w.balancer.Do(ctx, func(endpoint *url.URL) (err error) {
+		body, err = w.requestEndpoint(ctx, w.requestURL(endpoint, text))
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	// failed caching doesn't fail request
+	if errCache := w.cache.Put(w.cacheKey(text), body); errCache != nil {
+		logger.Error(errCache.Error())
+	}
+
+	return body, nil
+}
+
+func (w *QueryExecutor) requestEndpoint(ctx context.Context, urlRequest string) ([]byte, error) {
+	response, err := w.client.R().SetContext(ctx).Get(urlRequest)
+
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		return nil, balancer.ErrWrapUnavailable(err)
+	}
+
+	body, err := w.checkResponse(response)
+
+	if err != nil && response.StatusCode() >= http.StatusInternalServerError {
+		return nil, balancer.ErrWrapUnavailable(err)
+	}
+
+	return body, err
+}
+
 func (*QueryExecutor) checkResponse(resp *resty.Response) ([]byte, error) {
 	body := resp.Body()
 	status := resp.StatusCode()
@@ -95,21 +187,19 @@ func (*QueryExecutor) checkResponse(resp *resty.Response) ([]byte, error) {
 	return body, nil
 }
 
-func (w *QueryExecutor) do(ctx context.Context, urlRequest string) error {
-	response, err := w.client.R().SetContext(ctx).Get(urlRequest)
+func (w *QueryExecutor) do(ctx context.Context, query reader.Query) error {
+	body, err := w.request(ctx, query.Text)
 
 	if err != nil {
 		return err
 	}
 
-	body, err := w.checkResponse(response)
-
-	if err != nil {
-		return err
-	}
+	return w.send(ctx, Response{Query: query, Body: body})
+}
 
+func (w *QueryExecutor) send(ctx context.Context, response Response) error {
 	select {
-	case w.ResponseBodies <- body:
+	case w.ResponseBodies <- response:
 	case <-ctx.Done():
 		return ctx.Err()
 	}
@@ -2,28 +2,41 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log"
 	"log/slog"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
+	"time"
 
+	"wbx-script/searchType/cache"
 	"wbx-script/searchType/config"
 	"wbx-script/searchType/executor"
 	"wbx-script/searchType/logger"
+	"wbx-script/searchType/manifest"
+	"wbx-script/searchType/normalizer"
+	"wbx-script/searchType/notify"
 	"wbx-script/searchType/reader"
 	"wbx-script/searchType/saver"
+	"wbx-script/searchType/server"
 
 	"golang.org/x/sync/errgroup"
 )
 
+const (
+	toolName         = "searchType"
+	manifestFileName = "manifest.json"
+)
+
 type chain []func(ctx context.Context) error
 
 func newChain(tasks ...func(ctx context.Context) error) chain {
 	return tasks
 }
 
-func (c chain) run(ctx context.Context, limit int) {
+func (c chain) run(ctx context.Context, limit int) error {
 	errGroup, errGroupCtx := errgroup.WithContext(ctx)
 	errGroup.SetLimit(limit)
 
@@ -31,12 +44,23 @@ func (c chain) run(ctx context.Context, limit int) {
 		errGroup.Go(func() error { return c[i](errGroupCtx) })
 	}
 
-	if err := errGroup.Wait(); err != nil {
+	err := errGroup.Wait()
+
+	if err != nil {
 		logger.Error(err.Error())
 	}
+
+	return err
 }
 
+// failureExitCode lets jobs worker and CI distinguish failed run from complete one
+const failureExitCode = 1
+
 func main() {
+	os.Exit(run())
+}
+
+func run() int {
 	cfg, err := config.Parse()
 
 	if err != nil {
@@ -52,20 +76,117 @@ func main() {
 
 	defer logger.GlobalLogger.Stop()
 
-	queriesReader := reader.NewQueriesReader(cfg.QueriesPath)
-	queryExecutor := executor.NewQueryExecutor(cfg, queriesReader.Queries)
-	writer, err := saver.NewSaver(cfg, queryExecutor.ResponseBodies)
+	if cfg.Serve {
+		return serve(cfg)
+	}
+
+	notifier, err := notify.NewNotifier(cfg.Webhook)
 
 	if err != nil {
 		logger.Error(err.Error())
-		return
+		return failureExitCode
+	}
+
+	startedAt := time.Now()
+	runManifest, err := runBatch(cfg)
+
+	// run is notified on every exit path, failed notification doesn't change result of run
+	if errSend := notifier.Send(notify.Run{
+		Tool:      toolName,
+		StartedAt: startedAt,
+		Manifest:  runManifest,
+		Err:       err,
+		Output:    cfg.OutputPath,
+	}); errSend != nil {
+		logger.Error(errSend.Error())
+	}
+
+	if err != nil {
+		return failureExitCode
+	}
+
+	return 0
+}
+
+// runBatch returns nil manifest if run has failed before its manifest was started
+func runBatch(cfg *config.Config) (*manifest.Manifest, error) {
+	responseCache, err := cache.New(cfg.CacheDir, cfg.CacheTTL)
+
+	if err != nil {
+		logger.Error(err.Error())
+		return nil, err
+	}
+
+	queriesReader := reader.NewQueriesReader(cfg)
+	app := newChain(queriesReader.Run)
+	queries := queriesReader.Queries
+
+	var originals saver.Originals
+
+	if cfg.IsNormalizationEnabled() {
+		queriesNormalizer := normalizer.NewNormalizer(cfg, queries)
+		app = append(app, queriesNormalizer.Run)
+		queries = queriesNormalizer.Queries
+		originals = queriesNormalizer
+	}
+
+	queryExecutor := executor.NewQueryExecutor(cfg, queries, responseCache)
+	writer, err := saver.NewSaver(cfg, queryExecutor.ResponseBodies, originals)
+
+	if err != nil {
+		logger.Error(err.Error())
+		return nil, err
+	}
+
+	signalCtx, signalStop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+
+	defer signalStop()
+
+	app = append(app, queryExecutor.Run, writer.Run)
+
+	runManifest, err := manifest.Start(filepath.Join(cfg.OutputPath, manifestFileName), toolName, cfg.QueriesPath)
+
+	if err != nil {
+		logger.Error(err.Error())
+		return nil, err
+	}
+
+	errRun := app.run(signalCtx, cfg.GoErrGroupLimiter)
+
+	// tasks finish without error after signal, outputs of such run are partial and aren't committed
+	if errRun = errors.Join(errRun, signalCtx.Err()); errRun == nil {
+		if errRun = writer.Commit(); errRun != nil {
+			logger.Error(errRun.Error())
+		}
+	} else {
+		errRun = errors.Join(errRun, writer.Discard())
+	}
+
+	runManifest.SetCounts(writer.Counts())
+
+	if err = runManifest.Finish(errRun); err != nil {
+		logger.Error(err.Error())
+	}
+
+	return runManifest, errRun
+}
+
+func serve(cfg *config.Config) int {
+	responseCache, err := cache.New(cfg.CacheDir, cfg.CacheTTL)
+
+	if err != nil {
+		logger.Error(err.Error())
+		return failureExitCode
 	}
 
 	signalCtx, signalStop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 
 	defer signalStop()
 
-	app := newChain(queriesReader.Run, queryExecutor.Run, writer.Run)
+	if err = server.NewServer(cfg, responseCache).Run(signalCtx); err != nil {
+		logger.Error(err.Error())
+		return failureExitCode
+	}
 
-	app.run(signalCtx, cfg.GoErrGroupLimiter)
+	return 0
 }
@@ -0,0 +1,190 @@
+package manifest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"net/url"
+	"os"
+	"regexp"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"wbx-script/searchType/atomicfile"
+)
+
+const (
+	StatusRunning     = "running"
+	StatusComplete    = "complete"
+	StatusFailed      = "failed"
+	StatusInterrupted = "interrupted"
+)
+
+const redacted = "xxxxx"
+
+// Version is set on build by -ldflags "-X wbx-script/searchType/manifest.Version=..."
+var Version = "dev"
+
+// secretFlagRegex matches flags with secrets, webhook URLs keep their secret in path
+var secretFlagRegex = regexp.MustCompile(`(?i)password|secret|token|key|webhook-url`)
+
+type Manifest struct {
+	mu          *sync.Mutex
+	path        string
+	Tool        string            `json:"tool"`
+	Version     string            `json:"version"`
+	GitCommit   string            `json:"git_commit,omitempty"`
+	Status      string            `json:"status"`
+	StartedAt   time.Time         `json:"started_at"`
+	FinishedAt  *time.Time        `json:"finished_at,omitempty"`
+	Config      map[string]string `json:"config"`
+	InputFile   string            `json:"input_file,omitempty"`
+	InputSHA256 string            `json:"input_sha256,omitempty"`
+	Counts      map[string]int    `json:"counts,omitempty"`
+	Errors      []string          `json:"errors,omitempty"`
+}
+
+// Start writes manifest of running tool with effective values of parsed command line flags
+func Start(path, tool, inputPath string) (*Manifest, error) {
+	m := &Manifest{
+		mu:        &sync.Mutex{},
+		path:      path,
+		Tool:      tool,
+		Version:   Version,
+		GitCommit: gitCommit(),
+		Status:    StatusRunning,
+		StartedAt: time.Now(),
+		Config:    effectiveFlags(flag.CommandLine),
+		InputFile: inputPath,
+	}
+
+	if info, err := os.Stat(inputPath); err == nil && !info.IsDir() {
+		var errHash error
+
+		if m.InputSHA256, errHash = hashFile(inputPath); errHash != nil {
+			return nil, errHash
+		}
+	}
+
+	return m, m.write()
+}
+
+func (m *Manifest) SetCounts(counts map[string]int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.Counts = counts
+}
+
+// StatusOf returns status of finished run, canceled context means interruption by signal
+func StatusOf(runErr error) string {
+	switch {
+	case runErr == nil:
+		return StatusComplete
+	case errors.Is(runErr, context.Canceled):
+		return StatusInterrupted
+	default:
+		return StatusFailed
+	}
+}
+
+// ErrorsOf splits joined errors of run which are separated by newline
+func ErrorsOf(runErr error) []string {
+	if runErr == nil {
+		return nil
+	}
+
+	return strings.Split(runErr.Error(), "\n")
+}
+
+// Finish marks run as complete only if runErr is nil
+func (m *Manifest) Finish(runErr error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	finishedAt := time.Now()
+	m.FinishedAt = &finishedAt
+	m.Status = StatusOf(runErr)
+	m.Errors = ErrorsOf(runErr)
+
+	return m.write()
+}
+
+func (m *Manifest) write() error {
+	data, err := json.MarshalIndent(m, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	return atomicfile.WriteFile(m.path, data)
+}
+
+func effectiveFlags(flagSet *flag.FlagSet) map[string]string {
+	flags := make(map[string]string)
+
+	flagSet.VisitAll(func(f *flag.Flag) {
+		value := f.Value.String()
+
+		if secretFlagRegex.MatchString(f.Name) && value != "" {
+			value = redacted
+		} else if u, err := url.Parse(value); err == nil && u.User != nil {
+			value = u.Redacted()
+		}
+
+		flags[f.Name] = value
+	})
+
+	return flags
+}
+
+func hashFile(path string) (hash string, err error) {
+	file, err := os.Open(path)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer func() {
+		err = errors.Join(err, file.Close())
+	}()
+
+	hasher := sha256.New()
+
+	if _, err = io.Copy(hasher, file); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+func gitCommit() string {
+	info, ok := debug.ReadBuildInfo()
+
+	if !ok {
+		return ""
+	}
+
+	var revision, modified string
+
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			modified = setting.Value
+		}
+	}
+
+	if revision != "" && modified == "true" {
+		return revision + "-dirty"
+	}
+
+	return revision
+}
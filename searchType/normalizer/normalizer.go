@@ -0,0 +1,91 @@
+package normalizer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"wbx-script/searchType/config"
+	"wbx-script/searchType/logger"
+	"wbx-script/searchType/reader"
+)
+
+type Normalizer struct {
+	cfg       *config.Config
+	steps     []step
+	queries   <-chan reader.Query
+	Queries   chan reader.Query
+	mu        *sync.Mutex
+	originals map[string][]reader.Query
+}
+
+func NewNormalizer(cfg *config.Config, queries <-chan reader.Query) *Normalizer {
+	steps := make([]step, 0, len(cfg.NormalizeSteps))
+
+	for _, name := range cfg.NormalizeSteps {
+		steps = append(steps, stepsByName[name])
+	}
+
+	return &Normalizer{
+		cfg:       cfg,
+		steps:     steps,
+		queries:   queries,
+		Queries:   make(chan reader.Query),
+		mu:        &sync.Mutex{},
+		originals: make(map[string][]reader.Query),
+	}
+}
+
+func (n *Normalizer) normalize(text string) string {
+	for _, s := range n.steps {
+		text = s(text)
+	}
+
+	return text
+}
+
+// remember returns true if normalized query has to be sent further
+func (n *Normalizer) remember(original reader.Query, normalized string) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	seen, ok := n.originals[normalized]
+	n.originals[normalized] = append(seen, original)
+
+	return !ok || !n.cfg.Dedup
+}
+
+// Take returns originals of normalized query which haven't been taken yet
+func (n *Normalizer) Take(normalized string) []reader.Query {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	originals := n.originals[normalized]
+	n.originals[normalized] = originals[:0:0]
+
+	return originals
+}
+
+func (n *Normalizer) Run(ctx context.Context) error {
+	defer func() {
+		close(n.Queries)
+		logger.Info("Normalizer has finished")
+	}()
+
+	for original := range n.queries {
+		normalized := n.normalize(original.Text)
+
+		if !n.remember(original, normalized) {
+			logger.Info(fmt.Sprintf("Query %q is duplicate of %q", original.Text, normalized))
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case n.Queries <- reader.Query{Text: normalized, Weight: original.Weight}:
+		}
+	}
+
+	return nil
+}
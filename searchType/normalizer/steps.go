@@ -0,0 +1,26 @@
+package normalizer
+
+import (
+	"regexp"
+	"strings"
+
+	"wbx-script/searchType/config"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+type step func(string) string
+
+var spacesRegex = regexp.MustCompile(`\s+`)
+
+var stepsByName = map[string]step{
+	config.NormalizeTrim:   strings.TrimSpace,
+	config.NormalizeLower:  strings.ToLower,
+	config.NormalizeSpaces: collapseSpaces,
+	config.NormalizeNFKC:   norm.NFKC.String,
+	config.NormalizeYo:     strings.NewReplacer("ё", "е", "Ё", "Е").Replace,
+}
+
+func collapseSpaces(text string) string {
+	return spacesRegex.ReplaceAllString(text, " ")
+}
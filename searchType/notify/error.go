@@ -0,0 +1,12 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrResponseStatus = errors.New("bad status of webhook response")
+
+func ErrBadResponseStatus(status int, body string) error {
+	return fmt.Errorf("status - %d, answer - %q: %w", status, body, ErrResponseStatus)
+}
@@ -0,0 +1,162 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"os"
+	"text/template"
+	"time"
+
+	"wbx-script/searchType/manifest"
+
+	"github.com/go-resty/resty/v2"
+)
+
+const (
+	requestTimeout = 10 * time.Second
+	requestRetry   = 2
+)
+
+// Config of webhook, notifications are disabled without URL
+type Config struct {
+	URL          *url.URL
+	TemplatePath string
+}
+
+// Payload is sent as JSON or passed to template of request body
+type Payload struct {
+	Tool       string         `json:"tool"`
+	Version    string         `json:"version"`
+	Status     string         `json:"status"`
+	StartedAt  time.Time      `json:"started_at"`
+	FinishedAt time.Time      `json:"finished_at"`
+	Duration   string         `json:"duration"`
+	InputFile  string         `json:"input_file,omitempty"`
+	Output     string         `json:"output"`
+	Counts     map[string]int `json:"counts,omitempty"`
+	Errors     []string       `json:"errors,omitempty"`
+}
+
+// Run describes finished run, Manifest is nil if run has failed before its manifest was started
+type Run struct {
+	Tool      string
+	StartedAt time.Time
+	Manifest  *manifest.Manifest
+	Err       error
+	Output    string
+}
+
+var templateFuncs = template.FuncMap{
+	// json quotes value for templates of JSON bodies, e.g. {"text": {{ .Status | json }}}
+	"json": func(value any) (string, error) {
+		data, err := json.Marshal(value)
+		return string(data), err
+	},
+}
+
+type Notifier struct {
+	webhookURL *url.URL
+	body       *template.Template
+	client     *resty.Client
+}
+
+// NewNotifier returns nil notifier if webhook isn't configured, template is parsed before run to fail fast
+func NewNotifier(cfg Config) (*Notifier, error) {
+	if cfg.URL == nil {
+		return nil, nil
+	}
+
+	n := &Notifier{
+		webhookURL: cfg.URL,
+		client:     resty.New().SetTimeout(requestTimeout).SetRetryCount(requestRetry).SetDisableWarn(true),
+	}
+
+	if cfg.TemplatePath == "" {
+		return n, nil
+	}
+
+	content, err := os.ReadFile(cfg.TemplatePath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if n.body, err = template.New("webhook").Funcs(templateFuncs).Parse(string(content)); err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+func newPayload(run Run) Payload {
+	finishedAt := time.Now()
+	runManifest := run.Manifest
+
+	if runManifest == nil {
+		return Payload{
+			Tool:       run.Tool,
+			Version:    manifest.Version,
+			Status:     manifest.StatusOf(run.Err),
+			StartedAt:  run.StartedAt,
+			FinishedAt: finishedAt,
+			Duration:   finishedAt.Sub(run.StartedAt).Round(time.Second).String(),
+			Output:     run.Output,
+			Errors:     manifest.ErrorsOf(run.Err),
+		}
+	}
+
+	if runManifest.FinishedAt != nil {
+		finishedAt = *runManifest.FinishedAt
+	}
+
+	return Payload{
+		Tool:       runManifest.Tool,
+		Version:    runManifest.Version,
+		Status:     runManifest.Status,
+		StartedAt:  runManifest.StartedAt,
+		FinishedAt: finishedAt,
+		Duration:   finishedAt.Sub(runManifest.StartedAt).Round(time.Second).String(),
+		InputFile:  runManifest.InputFile,
+		Output:     run.Output,
+		Counts:     runManifest.Counts,
+		Errors:     runManifest.Errors,
+	}
+}
+
+// Send posts summary of finished run, it's called after interruption so context of run isn't used
+func (n *Notifier) Send(run Run) error {
+	if n == nil {
+		return nil
+	}
+
+	payload := newPayload(run)
+	body := &bytes.Buffer{}
+
+	var err error
+
+	if n.body == nil {
+		err = json.NewEncoder(body).Encode(payload)
+	} else {
+		err = n.body.Execute(body, payload)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	response, err := n.client.R().
+		SetHeader("Content-Type", "application/json").
+		SetBody(body.Bytes()).
+		Post(n.webhookURL.String())
+
+	if err != nil {
+		return err
+	}
+
+	if !response.IsSuccess() {
+		return ErrBadResponseStatus(response.StatusCode(), response.String())
+	}
+
+	return nil
+}
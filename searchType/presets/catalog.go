@@ -0,0 +1,24 @@
+package presets
+
+import (
+	"net/url"
+	"strings"
+)
+
+const semicolon = ";"
+
+// ParseCatalogValue parses query part of catalog url, semicolons are kept inside values
+func ParseCatalogValue(catalogValue string) (url.Values, error) {
+	return url.ParseQuery(strings.ReplaceAll(catalogValue, semicolon, url.PathEscape(semicolon)))
+}
+
+// FromCatalogValue returns presets referenced by catalog value
+func FromCatalogValue(catalogValue string) ([]string, error) {
+	queryParams, err := ParseCatalogValue(catalogValue)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return queryParams["preset"], nil
+}
@@ -0,0 +1,147 @@
+package presets
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+
+	"wbx-script/searchType/balancer"
+
+	"github.com/go-resty/resty/v2"
+	"golang.org/x/sync/errgroup"
+)
+
+// BadPresetMessage is answer of bucket server for preset which doesn't exist
+const BadPresetMessage = "preset param malformed"
+
+type bucketResponse struct {
+	Data struct {
+		Products []json.RawMessage `json:"products"`
+	} `json:"data"`
+}
+
+type ProbeResult struct {
+	Preset   string
+	Exists   bool
+	Products int
+	Err      error
+}
+
+type Prober struct {
+	balancer    *balancer.Balancer
+	client      *resty.Client
+	rateLimiter *time.Ticker
+	limit       int
+}
+
+func NewProber(bucketBalancer *balancer.Balancer, rps, retry, limit int, timeout time.Duration) *Prober {
+	return &Prober{
+		balancer:    bucketBalancer,
+		client:      resty.New().SetTimeout(timeout).SetRetryCount(retry).SetDisableWarn(true),
+		rateLimiter: time.NewTicker(time.Second / time.Duration(rps)),
+		limit:       limit,
+	}
+}
+
+func (p *Prober) Stop() {
+	p.rateLimiter.Stop()
+}
+
+func (p *Prober) waitLimiterAllowed(ctx context.Context) error {
+	select {
+	case <-p.rateLimiter.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (*Prober) presetURL(endpoint *url.URL, preset string) string {
+	bucketURL := *endpoint
+	q := bucketURL.Query()
+	q.Set("preset", preset)
+	bucketURL.RawQuery = q.Encode()
+
+	return bucketURL.String()
+}
+
+// Probe returns error only if context has been canceled, request errors are kept in result
+func (p *Prober) Probe(ctx context.Context, preset string) (ProbeResult, error) {
+	result := ProbeResult{Preset: preset}
+
+	if err := p.waitLimiterAllowed(ctx); err != nil {
+		return result, err
+	}
+
+	var response *resty.Response
+
+	// request fails over to other replicas only if replica is unavailable
+	err := p.balancer.Do(ctx, func(endpoint *url.URL) (err error) {
+		response, err = p.request(ctx, p.presetURL(endpoint, preset))
+		return err
+	})
+
+	if err != nil {
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+
+		result.Err = err
+
+		return result, nil
+	}
+
+	if response.StatusCode() == http.StatusBadRequest && response.String() == BadPresetMessage {
+		return result, nil
+	}
+
+	if !response.IsSuccess() {
+		result.Err = ErrBadResponseStatus(response.StatusCode(), response.String())
+		return result, nil
+	}
+
+	data := bucketResponse{}
+
+	if result.Err = json.Unmarshal(response.Body(), &data); result.Err == nil {
+		result.Exists = true
+		result.Products = len(data.Data.Products)
+	}
+
+	return result, nil
+}
+
+func (p *Prober) request(ctx context.Context, presetURL string) (*resty.Response, error) {
+	response, err := p.client.R().SetContext(ctx).Get(presetURL)
+
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		return nil, balancer.ErrWrapUnavailable(err)
+	}
+
+	if response.StatusCode() >= http.StatusInternalServerError {
+		return nil, balancer.ErrWrapUnavailable(ErrBadResponseStatus(response.StatusCode(), response.String()))
+	}
+
+	return response, nil
+}
+
+func (p *Prober) ProbeAll(ctx context.Context, presets []string) ([]ProbeResult, error) {
+	errGroup, errGroupCtx := errgroup.WithContext(ctx)
+	errGroup.SetLimit(p.limit)
+
+	results := make([]ProbeResult, len(presets))
+
+	for i, preset := range presets {
+		errGroup.Go(func() (err error) {
+			results[i], err = p.Probe(errGroupCtx, preset)
+			return err
+		})
+	}
+
+	return results, errGroup.Wait()
+}
@@ -0,0 +1,29 @@
+package reader
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrUnknownFormat = errors.New("unknown input format")
+	ErrNoColumn      = errors.New("there is no query column")
+	ErrNoField       = errors.New("there is no string field in json line")
+	ErrWeight        = errors.New("weight should be non-negative number")
+)
+
+func ErrWrapUnknownFormat(format string) error {
+	return fmt.Errorf("format - %q: %w", format, ErrUnknownFormat)
+}
+
+func ErrWrapNoColumn(name string) error {
+	return fmt.Errorf("column - %q: %w", name, ErrNoColumn)
+}
+
+func ErrWrapNoField(path string) error {
+	return fmt.Errorf("field - %q: %w", path, ErrNoField)
+}
+
+func ErrWrapInvalidWeight(value string, err error) error {
+	return fmt.Errorf("weight - %q: %w", value, errors.Join(err, ErrWeight))
+}
@@ -0,0 +1,217 @@
+package reader
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"wbx-script/searchType/config"
+)
+
+const dot = "."
+
+type queryScanner interface {
+	next() (Query, error)
+}
+
+func newQueryScanner(cfg *config.Config, r io.Reader) (queryScanner, error) {
+	switch cfg.InputFormat {
+	case config.FormatCSV:
+		return newCsvScanner(r, ',', cfg.QueryColumn, cfg.WeightColumn)
+	case config.FormatTSV:
+		return newCsvScanner(r, '\t', cfg.QueryColumn, cfg.WeightColumn)
+	case config.FormatJSONL:
+		return newJsonlScanner(r, cfg.QueryField, cfg.WeightField), nil
+	case config.FormatLines:
+		return &linesScanner{scanner: bufio.NewScanner(r)}, nil
+	default:
+		return nil, ErrWrapUnknownFormat(cfg.InputFormat)
+	}
+}
+
+func parseWeight(value string) (float64, error) {
+	weight, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+
+	if err != nil || weight < 0 {
+		return 0, ErrWrapInvalidWeight(value, err)
+	}
+
+	return weight, nil
+}
+
+type linesScanner struct {
+	scanner *bufio.Scanner
+}
+
+func (l *linesScanner) next() (Query, error) {
+	if !l.scanner.Scan() {
+		if err := l.scanner.Err(); err != nil {
+			return Query{}, err
+		}
+
+		return Query{}, io.EOF
+	}
+
+	return Query{Text: l.scanner.Text(), Weight: defaultWeight}, nil
+}
+
+type csvScanner struct {
+	reader       *csv.Reader
+	queryColumn  int
+	weightColumn int
+}
+
+func newCsvScanner(r io.Reader, separator rune, queryColumnName, weightColumnName string) (*csvScanner, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = separator
+
+	header, err := reader.Read()
+
+	if errors.Is(err, io.EOF) {
+		return nil, ErrWrapNoColumn(queryColumnName)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := &csvScanner{
+		reader:       reader,
+		queryColumn:  slices.Index(header, queryColumnName),
+		weightColumn: -1,
+	}
+
+	if scanner.queryColumn < 0 {
+		return nil, ErrWrapNoColumn(queryColumnName)
+	}
+
+	if weightColumnName != "" {
+		if scanner.weightColumn = slices.Index(header, weightColumnName); scanner.weightColumn < 0 {
+			return nil, ErrWrapNoColumn(weightColumnName)
+		}
+	}
+
+	return scanner, nil
+}
+
+func (c *csvScanner) next() (Query, error) {
+	record, err := c.reader.Read()
+
+	if err != nil {
+		return Query{}, err
+	}
+
+	if c.queryColumn >= len(record) || c.weightColumn >= len(record) {
+		return Query{}, ErrWrapNoColumn(fmt.Sprintf("#%d", max(c.queryColumn, c.weightColumn)))
+	}
+
+	query := Query{Text: record[c.queryColumn], Weight: defaultWeight}
+
+	if c.weightColumn >= 0 {
+		if query.Weight, err = parseWeight(record[c.weightColumn]); err != nil {
+			return Query{}, err
+		}
+	}
+
+	return query, nil
+}
+
+type jsonlScanner struct {
+	scanner    *bufio.Scanner
+	queryPath  []string
+	weightPath []string
+}
+
+func newJsonlScanner(r io.Reader, queryField, weightField string) *jsonlScanner {
+	scanner := &jsonlScanner{
+		scanner:   bufio.NewScanner(r),
+		queryPath: strings.Split(queryField, dot),
+	}
+
+	if weightField != "" {
+		scanner.weightPath = strings.Split(weightField, dot)
+	}
+
+	return scanner
+}
+
+func (j *jsonlScanner) next() (Query, error) {
+	for j.scanner.Scan() {
+		line := j.scanner.Bytes()
+
+		if len(strings.TrimSpace(string(line))) == 0 {
+			continue
+		}
+
+		return j.parse(line)
+	}
+
+	if err := j.scanner.Err(); err != nil {
+		return Query{}, err
+	}
+
+	return Query{}, io.EOF
+}
+
+func (j *jsonlScanner) parse(line []byte) (Query, error) {
+	var object map[string]any
+
+	if err := json.Unmarshal(line, &object); err != nil {
+		return Query{}, err
+	}
+
+	text, err := lookupField(object, j.queryPath)
+
+	if err != nil {
+		return Query{}, err
+	}
+
+	query := Query{Text: text, Weight: defaultWeight}
+
+	if j.weightPath == nil {
+		return query, nil
+	}
+
+	weight, err := lookupField(object, j.weightPath)
+
+	if err != nil {
+		return Query{}, err
+	}
+
+	if query.Weight, err = parseWeight(weight); err != nil {
+		return Query{}, err
+	}
+
+	return query, nil
+}
+
+func lookupField(object map[string]any, fieldPath []string) (string, error) {
+	var value any = object
+
+	for _, name := range fieldPath {
+		nested, ok := value.(map[string]any)
+
+		if !ok {
+			return "", ErrWrapNoField(strings.Join(fieldPath, dot))
+		}
+
+		if value, ok = nested[name]; !ok {
+			return "", ErrWrapNoField(strings.Join(fieldPath, dot))
+		}
+	}
+
+	switch typed := value.(type) {
+	case string:
+		return typed, nil
+	case float64, bool:
+		return fmt.Sprint(typed), nil
+	default:
+		return "", ErrWrapNoField(strings.Join(fieldPath, dot))
+	}
+}
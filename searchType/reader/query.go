@@ -0,0 +1,8 @@
+package reader
+
+const defaultWeight = 1
+
+type Query struct {
+	Text   string
+	Weight float64
+}
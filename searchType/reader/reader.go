@@ -1,24 +1,25 @@
 package reader
 
 import (
-	"bufio"
 	"context"
 	"errors"
 	"fmt"
-	"os"
+	"io"
 
+	"wbx-script/searchType/config"
 	"wbx-script/searchType/logger"
+	"wbx-script/searchType/sampling"
 )
 
 type QueriesReader struct {
-	pathToQueries string
-	Queries       chan string
+	cfg     *config.Config
+	Queries chan Query
 }
 
-func NewQueriesReader(pathToQueries string) *QueriesReader {
+func NewQueriesReader(cfg *config.Config) *QueriesReader {
 	return &QueriesReader{
-		Queries:       make(chan string),
-		pathToQueries: pathToQueries,
+		Queries: make(chan Query),
+		cfg:     cfg,
 	}
 }
 
@@ -28,25 +29,63 @@ func (p *QueriesReader) Run(ctx context.Context) (err error) {
 		logger.Info("QueriesReader has finished")
 	}()
 
-	file, err := os.Open(p.pathToQueries)
+	src, err := openSource(p.cfg.QueriesPath)
 	if err != nil {
 		return err
 	}
 
 	defer func() {
-		err = errors.Join(err, file.Close())
+		err = errors.Join(err, src.Close())
 	}()
 
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		text := scanner.Text()
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case p.Queries <- text:
-			logger.Info(fmt.Sprintf("Query %q has been read", text))
+	scanner, err := newQueryScanner(p.cfg, src)
+	if err != nil {
+		return err
+	}
+
+	var (
+		query      Query
+		pass, done bool
+	)
+
+	sampler := sampling.NewSampler[Query](p.cfg.Sampling)
+
+	for !done {
+		query, err = scanner.next()
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if pass, done = sampler.Add(query, query.Weight); !pass {
+			continue
+		}
+
+		if err = p.send(ctx, query); err != nil {
+			return err
+		}
+	}
+
+	for _, query = range sampler.Rest() {
+		if err = p.send(ctx, query); err != nil {
+			return err
 		}
 	}
 
-	return
+	return nil
+}
+
+func (p *QueriesReader) send(ctx context.Context, query Query) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case p.Queries <- query:
+		logger.Info(fmt.Sprintf("Query %q has been read", query.Text))
+	}
+
+	return nil
 }
@@ -0,0 +1,90 @@
+package reader
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+
+	"wbx-script/searchType/config"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+type source struct {
+	io.Reader
+	closers []func() error
+}
+
+func (s *source) Close() error {
+	var err error
+
+	for i := len(s.closers) - 1; i >= 0; i-- {
+		err = errors.Join(err, s.closers[i]())
+	}
+
+	return err
+}
+
+func openSource(path string) (*source, error) {
+	var file io.ReadCloser = os.Stdin
+
+	if path != config.StdinPath {
+		var err error
+
+		if file, err = os.Open(path); err != nil {
+			return nil, err
+		}
+	}
+
+	src := &source{closers: []func() error{file.Close}}
+
+	if err := src.decompress(bufio.NewReader(file)); err != nil {
+		return nil, errors.Join(src.Close(), err)
+	}
+
+	return src, nil
+}
+
+func (s *source) decompress(buffered *bufio.Reader) error {
+	header, err := buffered.Peek(len(zstdMagic))
+
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	switch {
+	case bytes.HasPrefix(header, gzipMagic):
+		gzipReader, errGzip := gzip.NewReader(buffered)
+
+		if errGzip != nil {
+			return errGzip
+		}
+
+		s.Reader = gzipReader
+		s.closers = append(s.closers, gzipReader.Close)
+	case bytes.HasPrefix(header, zstdMagic):
+		zstdReader, errZstd := zstd.NewReader(buffered)
+
+		if errZstd != nil {
+			return errZstd
+		}
+
+		s.Reader = zstdReader
+		s.closers = append(s.closers, func() error {
+			zstdReader.Close()
+			return nil
+		})
+	default:
+		s.Reader = buffered
+	}
+
+	return nil
+}
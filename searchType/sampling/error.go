@@ -0,0 +1,9 @@
+package sampling
+
+import "errors"
+
+var (
+	ErrMode       = errors.New("sample mode should be one of first, random, stratified, nth")
+	ErrSize       = errors.New("sample size cannot be less than zero or equal")
+	ErrStratified = errors.New("stratified sampling requires weight of rows")
+)
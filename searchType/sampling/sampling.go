@@ -0,0 +1,179 @@
+package sampling
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+	"sort"
+)
+
+const (
+	ModeNone       = ""
+	ModeFirst      = "first"
+	ModeRandom     = "random"
+	ModeStratified = "stratified"
+	ModeNth        = "nth"
+)
+
+type Config struct {
+	Mode string
+	// number of rows for first, random and stratified modes, step for nth mode
+	Size int
+	Seed int64
+}
+
+func (c Config) Validate(isWeighted bool) error {
+	switch c.Mode {
+	case ModeNone:
+		return nil
+	case ModeFirst, ModeRandom, ModeNth:
+	case ModeStratified:
+		if !isWeighted {
+			return ErrStratified
+		}
+	default:
+		return ErrMode
+	}
+
+	if c.Size <= 0 {
+		return ErrSize
+	}
+
+	return nil
+}
+
+type item[T any] struct {
+	index int
+	value T
+}
+
+type stratum[T any] struct {
+	count     int
+	reservoir []item[T]
+}
+
+type Sampler[T any] struct {
+	cfg    Config
+	rnd    *rand.Rand
+	seen   int
+	strata map[int]*stratum[T]
+}
+
+func NewSampler[T any](cfg Config) *Sampler[T] {
+	return &Sampler[T]{
+		cfg:    cfg,
+		rnd:    rand.New(rand.NewSource(cfg.Seed)),
+		strata: make(map[int]*stratum[T]),
+	}
+}
+
+// Add returns whether value has to be passed right now and whether rest of input can be skipped.
+// Values of random and stratified modes are passed only after input end by Rest.
+func (s *Sampler[T]) Add(value T, weight float64) (pass, done bool) {
+	s.seen++
+
+	switch s.cfg.Mode {
+	case ModeFirst:
+		return s.seen <= s.cfg.Size, s.seen >= s.cfg.Size
+	case ModeNth:
+		return (s.seen-1)%s.cfg.Size == 0, false
+	case ModeRandom:
+		s.addToStratum(0, value)
+		return false, false
+	case ModeStratified:
+		s.addToStratum(stratumOf(weight), value)
+		return false, false
+	default:
+		return true, false
+	}
+}
+
+func stratumOf(weight float64) int {
+	if weight <= 0 {
+		return math.MinInt
+	}
+
+	return int(math.Floor(math.Log10(weight)))
+}
+
+func (s *Sampler[T]) addToStratum(key int, value T) {
+	st, ok := s.strata[key]
+
+	if !ok {
+		st = &stratum[T]{}
+		s.strata[key] = st
+	}
+
+	st.count++
+
+	if len(st.reservoir) < s.cfg.Size {
+		st.reservoir = append(st.reservoir, item[T]{s.seen, value})
+		return
+	}
+
+	if j := s.rnd.Intn(st.count); j < s.cfg.Size {
+		st.reservoir[j] = item[T]{s.seen, value}
+	}
+}
+
+// Rest returns sampled values of random and stratified modes in input order
+func (s *Sampler[T]) Rest() []T {
+	keys := make([]int, 0, len(s.strata))
+	for key := range s.strata {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	quotas := s.quotas(keys)
+	sampled := make([]item[T], 0, s.cfg.Size)
+
+	for i, key := range keys {
+		reservoir := s.strata[key].reservoir
+		s.rnd.Shuffle(len(reservoir), func(a, b int) { reservoir[a], reservoir[b] = reservoir[b], reservoir[a] })
+		sampled = append(sampled, reservoir[:quotas[i]]...)
+	}
+
+	slices.SortFunc(sampled, func(a, b item[T]) int { return a.index - b.index })
+
+	values := make([]T, 0, len(sampled))
+	for _, it := range sampled {
+		values = append(values, it.value)
+	}
+
+	return values
+}
+
+// quotas splits sample size between strata proportionally to their counts by largest remainder method
+func (s *Sampler[T]) quotas(keys []int) []int {
+	quotas := make([]int, len(keys))
+	remainders := make([]float64, len(keys))
+	left := min(s.cfg.Size, s.seen)
+
+	for i, key := range keys {
+		exact := float64(s.strata[key].count) * float64(s.cfg.Size) / float64(s.seen)
+		quotas[i] = min(int(exact), len(s.strata[key].reservoir))
+		remainders[i] = exact - float64(quotas[i])
+		left -= quotas[i]
+	}
+
+	order := make([]int, len(keys))
+	for i := range order {
+		order[i] = i
+	}
+
+	sort.SliceStable(order, func(a, b int) bool { return remainders[order[a]] > remainders[order[b]] })
+
+	for _, i := range order {
+		if left <= 0 {
+			break
+		}
+
+		if quotas[i] < len(s.strata[keys[i]].reservoir) {
+			quotas[i]++
+			left--
+		}
+	}
+
+	return quotas
+}
@@ -0,0 +1,45 @@
+package saver
+
+import (
+	"net/url"
+
+	"wbx-script/searchType/presets"
+)
+
+type Classification struct {
+	Name         string
+	Category     string
+	CatalogValue string
+	Presets      []string
+	Params       url.Values
+}
+
+// Classify parses single response of extend match server the same way as batch run does
+func Classify(body []byte) (Classification, error) {
+	qInfo, err := parseResponse(body)
+
+	if err != nil {
+		return Classification{}, err
+	}
+
+	return Classification{
+		Name:         qInfo.text,
+		Category:     string(qInfo.category),
+		CatalogValue: qInfo.filterValue,
+		Presets:      qInfo.presets,
+		Params:       qInfo.params,
+	}, nil
+}
+
+// CategoryOf detects category of catalog value the same way as batch run does
+func CategoryOf(catalogValue string) (string, error) {
+	queryParams, err := presets.ParseCatalogValue(catalogValue)
+
+	if err != nil {
+		return "", err
+	}
+
+	_, isPresetInside := queryParams["preset"]
+
+	return string(detectCategory(checkTokenInside(queryParams), isPresetInside)), nil
+}
@@ -0,0 +1,88 @@
+package saver
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"wbx-script/searchType/logger"
+)
+
+const (
+	presetsReportFileName = "presets_report.csv"
+	queriesSeparator      = " | "
+)
+
+type presetUsage struct {
+	categories map[QueryCategory]struct{}
+	queries    map[string]struct{}
+}
+
+func (s *Saver) rememberPresets(qInfo queryInfo, queryText string) {
+	for _, presetID := range qInfo.presets {
+		usage, ok := s.presetUsages[presetID]
+
+		if !ok {
+			usage = &presetUsage{
+				categories: make(map[QueryCategory]struct{}),
+				queries:    make(map[string]struct{}),
+			}
+			s.presetUsages[presetID] = usage
+		}
+
+		usage.categories[qInfo.category] = struct{}{}
+		usage.queries[queryText] = struct{}{}
+	}
+}
+
+func sortedKeys[K ~string, V any](set map[K]V) []string {
+	keys := make([]string, 0, len(set))
+
+	for key := range set {
+		keys = append(keys, string(key))
+	}
+
+	slices.Sort(keys)
+
+	return keys
+}
+
+// writePresetsReport checks every collected preset on bucket server
+func (s *Saver) writePresetsReport(ctx context.Context) error {
+	presetIDs := sortedKeys(s.presetUsages)
+
+	logger.Info("Checking " + strconv.Itoa(len(presetIDs)) + " presets on bucket server")
+
+	results, err := s.prober.ProbeAll(ctx, presetIDs)
+
+	if err != nil {
+		return err
+	}
+
+	sep := s.cfg.CsvSeparator
+	lines := []string{
+		"preset" + sep + "exists" + sep + "products" + sep + "categories" + sep + "queries_count" + sep + "queries" + sep + "error",
+	}
+
+	for _, result := range results {
+		usage := s.presetUsages[result.Preset]
+		errText := ""
+
+		if result.Err != nil {
+			errText = strings.ReplaceAll(result.Err.Error(), sep, " ")
+		}
+
+		lines = append(lines, result.Preset+
+			sep+strconv.FormatBool(result.Exists)+
+			sep+strconv.Itoa(result.Products)+
+			sep+strings.Join(sortedKeys(usage.categories), comma)+
+			sep+strconv.Itoa(len(usage.queries))+
+			sep+strings.Join(sortedKeys(usage.queries), queriesSeparator)+
+			sep+errText,
+		)
+	}
+
+	return s.writeLines(filepath.Join(s.cfg.OutputPath, presetsReportFileName), lines)
+}
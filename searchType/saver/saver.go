@@ -13,8 +13,13 @@ import (
 	"slices"
 	"strings"
 
+	"wbx-script/searchType/atomicfile"
+	"wbx-script/searchType/balancer"
 	"wbx-script/searchType/config"
+	"wbx-script/searchType/executor"
 	"wbx-script/searchType/logger"
+	"wbx-script/searchType/presets"
+	"wbx-script/searchType/reader"
 )
 
 type QueryCategory string
@@ -27,13 +32,13 @@ const (
 )
 
 const (
-	presetsFileName = "list.presets"
-	queriesFileName = "queries.csv"
+	PresetsFileName = "list.presets"
+	QueriesFileName = "queries.csv"
 )
 
 const (
-	semicolon = ";"
-	newline   = "\n"
+	comma   = ","
+	newline = "\n"
 )
 
 var allExistedValidTypes = []QueryCategory{preset, extendSearch, merger}
@@ -43,11 +48,12 @@ type queryInfo struct {
 	category    QueryCategory
 	filterValue string
 	presets     []string
+	params      url.Values
 }
 
 type CategoryPresetsMap map[QueryCategory]map[string]struct{}
 
-var unknownQueryInfo = queryInfo{"", unknown, "", nil}
+var unknownQueryInfo = queryInfo{"", unknown, "", nil, nil}
 
 type ExactMatchResponse struct {
 	Metadata struct {
@@ -56,15 +62,24 @@ type ExactMatchResponse struct {
 	} `json:"metadata"`
 }
 
+type Originals interface {
+	Take(query string) []reader.Query
+}
+
 type Saver struct {
 	cfg                  *config.Config
-	responseBodies       <-chan []byte
+	responseBodies       <-chan executor.Response
 	categoryToWriter     map[QueryCategory]*bufio.Writer
 	categoryToSetPresets CategoryPresetsMap
-	openedFiles          []*os.File
+	openedFiles          []*atomicfile.File
+	originals            Originals
+	classified           map[string]queryInfo
+	stats                *statistics
+	prober               *presets.Prober
+	presetUsages         map[string]*presetUsage
 }
 
-func closeFiles(files []*os.File) error {
+func closeFiles(files []*atomicfile.File) error {
 	var err error
 
 	for i := range files {
@@ -74,15 +89,25 @@ func closeFiles(files []*os.File) error {
 	return err
 }
 
-func (s *Saver) openFileByType(typeOfSearch QueryCategory) (*os.File, error) {
-	directory := filepath.Join(filepath.Dir(s.cfg.QueriesPath), string(typeOfSearch))
+func commitFiles(files []*atomicfile.File) error {
+	var err error
+
+	for i := range files {
+		err = errors.Join(files[i].Commit(), err)
+	}
+
+	return err
+}
+
+func (s *Saver) openFileByType(typeOfSearch QueryCategory) (*atomicfile.File, error) {
+	directory := filepath.Join(s.cfg.OutputPath, string(typeOfSearch))
 
 	if err := os.MkdirAll(directory, os.ModePerm); err != nil {
 		return nil, err
 	}
 
-	newFilepath := filepath.Join(directory, queriesFileName)
-	file, err := os.OpenFile(newFilepath, os.O_WRONLY|os.O_TRUNC|os.O_CREATE, os.ModePerm)
+	newFilepath := filepath.Join(directory, QueriesFileName)
+	file, err := atomicfile.Create(newFilepath)
 
 	if err != nil {
 		return nil, err
@@ -95,10 +120,10 @@ func (s *Saver) openFileByType(typeOfSearch QueryCategory) (*os.File, error) {
 	return file, nil
 }
 
-func NewSaver(cfg *config.Config, responseBodies <-chan []byte) (*Saver, error) {
+func NewSaver(cfg *config.Config, responseBodies <-chan executor.Response, originals Originals) (*Saver, error) {
 	var (
 		err  error
-		file *os.File
+		file *atomicfile.File
 	)
 
 	s := &Saver{
@@ -106,8 +131,22 @@ func NewSaver(cfg *config.Config, responseBodies <-chan []byte) (*Saver, error)
 		cfg:                  cfg,
 		categoryToWriter:     make(map[QueryCategory]*bufio.Writer, len(allExistedValidTypes)),
 		categoryToSetPresets: make(CategoryPresetsMap),
+		originals:            originals,
+		classified:           make(map[string]queryInfo),
+		stats:                newStatistics(cfg.TopN),
+		presetUsages:         make(map[string]*presetUsage),
+	}
+
+	if cfg.EnrichPresets {
+		s.prober = presets.NewProber(
+			balancer.New(cfg.BucketServerURLs, cfg.Balancer),
+			cfg.Rps,
+			cfg.CountOfRetry,
+			cfg.GoErrGroupLimiter,
+			cfg.Timeout,
+		)
 	}
-	s.openedFiles = make([]*os.File, 0, len(allExistedValidTypes))
+	s.openedFiles = make([]*atomicfile.File, 0, len(allExistedValidTypes))
 
 	for _, queryType := range allExistedValidTypes {
 		file, err = s.openFileByType(queryType)
@@ -124,6 +163,11 @@ func NewSaver(cfg *config.Config, responseBodies <-chan []byte) (*Saver, error)
 	return s, nil
 }
 
+// Counts returns number of saved queries per category, it's safe to call after Run has finished
+func (s *Saver) Counts() map[string]int {
+	return s.stats.counts()
+}
+
 func (s *Saver) closeAll() error {
 	if err := closeFiles(s.openedFiles); err != nil {
 		return err
@@ -134,6 +178,16 @@ func (s *Saver) closeAll() error {
 	return nil
 }
 
+// Commit renames all written files into place, it's called only after the whole run has succeeded
+func (s *Saver) Commit() error {
+	return errors.Join(commitFiles(s.openedFiles), s.closeAll())
+}
+
+// Discard removes written files of failed or interrupted run, previous outputs are kept
+func (s *Saver) Discard() error {
+	return s.closeAll()
+}
+
 func (s *Saver) flushAll() error {
 	var err error
 
@@ -183,8 +237,7 @@ func parseResponse(textBytes []byte) (queryInfo, error) {
 		return unknownQueryInfo, err
 	}
 
-	queryParamsStr := strings.ReplaceAll(respData.Metadata.CatalogValue, semicolon, url.PathEscape(semicolon))
-	queryParams, err := url.ParseQuery(queryParamsStr)
+	queryParams, err := presets.ParseCatalogValue(respData.Metadata.CatalogValue)
 
 	if err != nil {
 		return unknownQueryInfo, err
@@ -193,7 +246,7 @@ func parseResponse(textBytes []byte) (queryInfo, error) {
 	presetsStr, isPresetInside := queryParams["preset"]
 	isTokenInside := checkTokenInside(queryParams)
 
-	qInfo := queryInfo{respData.Metadata.Name, unknown, respData.Metadata.CatalogValue, nil}
+	qInfo := queryInfo{respData.Metadata.Name, unknown, respData.Metadata.CatalogValue, nil, queryParams}
 	qInfo.presets = presetsStr
 	qInfo.category = detectCategory(isTokenInside, isPresetInside)
 
@@ -220,9 +273,16 @@ func (s *Saver) validate(info queryInfo) error {
 	return nil
 }
 
-func (s *Saver) Run(_ context.Context) (err error) {
+func (s *Saver) Run(ctx context.Context) (err error) {
 	defer func() {
-		err = errors.Join(s.flushAll(), s.closeAll(), err)
+		if s.prober != nil {
+			s.prober.Stop()
+		}
+
+		// files are kept open to be committed after other tasks of run have succeeded too
+		if err = errors.Join(s.flushAll(), err); err != nil {
+			err = errors.Join(s.Discard(), err)
+		}
 
 		logger.Info("Saver has finished")
 	}()
@@ -230,7 +290,7 @@ func (s *Saver) Run(_ context.Context) (err error) {
 	var qInfo queryInfo
 
 	for value := range s.responseBodies {
-		qInfo, err = parseResponse(value)
+		qInfo, err = parseResponse(value.Body)
 
 		if err != nil {
 			return err
@@ -240,18 +300,19 @@ func (s *Saver) Run(_ context.Context) (err error) {
 			switch {
 			case errors.Is(err, ErrUnknownCategory):
 				logger.Info(fmt.Sprintf("Query %q has been skipped", qInfo.text))
-				continue
 			default:
 				return err
 			}
 		}
 
-		text := qInfo.text + s.cfg.CsvSeparator + qInfo.filterValue + newline
-
-		if _, err = s.categoryToWriter[qInfo.category].WriteString(text); err != nil {
+		if err = s.write(value.Query, qInfo); err != nil {
 			return err
 		}
 
+		if qInfo.category == unknown {
+			continue
+		}
+
 		if presetMap, ok := s.categoryToSetPresets[qInfo.category]; ok {
 			for _, presetID := range qInfo.presets {
 				presetMap[presetID] = struct{}{}
@@ -261,7 +322,66 @@ func (s *Saver) Run(_ context.Context) (err error) {
 		logger.Info(fmt.Sprintf("%q query has finished", qInfo.text))
 	}
 
-	return s.writePresets()
+	if err = s.writeLateOriginals(); err != nil {
+		return err
+	}
+
+	if err = s.writePresets(); err != nil {
+		return err
+	}
+
+	if err = s.writeSummary(); err != nil {
+		return err
+	}
+
+	if s.prober == nil {
+		return nil
+	}
+
+	return s.writePresetsReport(ctx)
+}
+
+// unknown category is only counted in statistics
+func (s *Saver) write(query reader.Query, qInfo queryInfo) error {
+	queries := []reader.Query{{Text: qInfo.text, Weight: query.Weight}}
+
+	if s.originals != nil {
+		queries = s.originals.Take(query.Text)
+		s.classified[query.Text] = qInfo
+	}
+
+	for _, q := range queries {
+		s.stats.add(qInfo.category, q, qInfo.filterValue)
+
+		if qInfo.category == unknown {
+			continue
+		}
+
+		s.rememberPresets(qInfo, q.Text)
+
+		if strings.Contains(q.Text, s.cfg.CsvSeparator) {
+			return ErrInvalidResponseSeparator(q.Text, s.cfg.CsvSeparator)
+		}
+
+		line := q.Text + s.cfg.CsvSeparator + qInfo.filterValue + newline
+
+		if _, err := s.categoryToWriter[qInfo.category].WriteString(line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// duplicates could be read after response of their normalized query has been written
+func (s *Saver) writeLateOriginals() error {
+	for query, qInfo := range s.classified {
+		if err := s.write(reader.Query{Text: query}, qInfo); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (*Saver) getSortedPresets(setOfPresets map[string]struct{}) []string {
@@ -279,9 +399,9 @@ func (s *Saver) writePresets() error {
 	var errSum error
 
 	for category, setOfPresets := range s.categoryToSetPresets {
-		directory := filepath.Join(filepath.Dir(s.cfg.QueriesPath), string(category))
-		pathToFile := filepath.Join(directory, presetsFileName)
-		file, err1 := os.OpenFile(pathToFile, os.O_WRONLY|os.O_TRUNC|os.O_CREATE, os.ModePerm)
+		directory := filepath.Join(s.cfg.OutputPath, string(category))
+		pathToFile := filepath.Join(directory, PresetsFileName)
+		file, err1 := atomicfile.Create(pathToFile)
 
 		if err1 != nil {
 			errSum = errors.Join(err1, errSum)
@@ -0,0 +1,149 @@
+package saver
+
+import (
+	"bufio"
+	"errors"
+	"path/filepath"
+	"slices"
+	"strconv"
+
+	"wbx-script/searchType/atomicfile"
+	"wbx-script/searchType/reader"
+)
+
+const (
+	summaryFileName    = "summary.csv"
+	topQueriesFileName = "top_queries.csv"
+)
+
+type weightedQuery struct {
+	text        string
+	filterValue string
+	weight      float64
+}
+
+type categoryStats struct {
+	queries int
+	weight  float64
+	top     []weightedQuery
+}
+
+type statistics struct {
+	topN         int
+	categories   map[QueryCategory]*categoryStats
+	totalQueries int
+	totalWeight  float64
+}
+
+func newStatistics(topN int) *statistics {
+	return &statistics{
+		topN:       topN,
+		categories: make(map[QueryCategory]*categoryStats),
+	}
+}
+
+func (s *statistics) add(category QueryCategory, query reader.Query, filterValue string) {
+	stats, ok := s.categories[category]
+
+	if !ok {
+		stats = &categoryStats{}
+		s.categories[category] = stats
+	}
+
+	stats.queries++
+	stats.weight += query.Weight
+	s.totalQueries++
+	s.totalWeight += query.Weight
+
+	position := slices.IndexFunc(stats.top, func(q weightedQuery) bool { return q.weight < query.Weight })
+
+	if position < 0 {
+		position = len(stats.top)
+	}
+
+	if position < s.topN {
+		stats.top = slices.Insert(stats.top, position, weightedQuery{query.Text, filterValue, query.Weight})
+		stats.top = stats.top[:min(len(stats.top), s.topN)]
+	}
+}
+
+func (s *statistics) counts() map[string]int {
+	counts := make(map[string]int, len(s.categories))
+
+	for category, categoryStat := range s.categories {
+		counts[string(category)] = categoryStat.queries
+	}
+
+	return counts
+}
+
+func share(part, total float64) string {
+	if total == 0 {
+		total = 1
+	}
+
+	return strconv.FormatFloat(part/total, 'f', 4, 64)
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func (s *Saver) writeSummary() error {
+	sep := s.cfg.CsvSeparator
+	lines := []string{"category" + sep + "queries" + sep + "queries_share" + sep + "weight" + sep + "weight_share"}
+	stats := s.stats
+
+	for _, category := range append(slices.Clone(allExistedValidTypes), unknown) {
+		categoryStat, ok := stats.categories[category]
+
+		if !ok {
+			categoryStat = &categoryStats{}
+		}
+
+		lines = append(lines, string(category)+
+			sep+strconv.Itoa(categoryStat.queries)+
+			sep+share(float64(categoryStat.queries), float64(stats.totalQueries))+
+			sep+formatFloat(categoryStat.weight)+
+			sep+share(categoryStat.weight, stats.totalWeight),
+		)
+	}
+
+	directory := s.cfg.OutputPath
+	err := s.writeLines(filepath.Join(directory, summaryFileName), lines)
+
+	for _, category := range allExistedValidTypes {
+		lines = []string{"text" + sep + "weight" + sep + "query"}
+
+		if categoryStat, ok := stats.categories[category]; ok {
+			for _, q := range categoryStat.top {
+				lines = append(lines, q.text+sep+formatFloat(q.weight)+sep+q.filterValue)
+			}
+		}
+
+		err = errors.Join(err, s.writeLines(filepath.Join(directory, string(category), topQueriesFileName), lines))
+	}
+
+	return err
+}
+
+// writeLines writes file which is committed with other opened files of saver
+func (s *Saver) writeLines(filePath string, lines []string) error {
+	file, err := atomicfile.Create(filePath)
+
+	if err != nil {
+		return err
+	}
+
+	s.openedFiles = append(s.openedFiles, file)
+
+	writer := bufio.NewWriter(file)
+
+	for _, line := range lines {
+		if _, err = writer.WriteString(line + newline); err != nil {
+			return err
+		}
+	}
+
+	return writer.Flush()
+}
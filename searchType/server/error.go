@@ -0,0 +1,16 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrEmptyRequest = errors.New("request should contain query or queries")
+	ErrBothQueries  = errors.New("request cannot contain both query and queries")
+	ErrBatchSize    = errors.New("too many queries in request")
+)
+
+func ErrWrapBatchSize(size, limit int) error {
+	return fmt.Errorf("got %d, limit is %d: %w", size, limit, ErrBatchSize)
+}
@@ -0,0 +1,184 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"wbx-script/searchType/cache"
+	"wbx-script/searchType/config"
+	"wbx-script/searchType/executor"
+	"wbx-script/searchType/logger"
+	"wbx-script/searchType/saver"
+
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	shutdownTimeout   = 10 * time.Second
+	readHeaderTimeout = 10 * time.Second
+	maxBodySize       = 1 << 20
+)
+
+type classifyRequest struct {
+	Query   string   `json:"query"`
+	Queries []string `json:"queries"`
+}
+
+type classifyResult struct {
+	Query        string              `json:"query"`
+	Name         string              `json:"name,omitempty"`
+	Category     string              `json:"category,omitempty"`
+	CatalogValue string              `json:"catalog_value,omitempty"`
+	Presets      []string            `json:"presets,omitempty"`
+	Params       map[string][]string `json:"params,omitempty"`
+	Error        string              `json:"error,omitempty"`
+}
+
+type batchResponse struct {
+	Results []classifyResult `json:"results"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Server classifies queries on demand with the same requests to extend match server as batch run
+type Server struct {
+	cfg        *config.Config
+	executor   *executor.QueryExecutor
+	cache      *cache.Cache
+	httpServer *http.Server
+}
+
+func NewServer(cfg *config.Config, responseCache *cache.Cache) *Server {
+	s := &Server{
+		cfg:      cfg,
+		executor: executor.NewQueryExecutor(cfg, nil, responseCache),
+		cache:    responseCache,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /classify", s.handleClassify)
+
+	s.httpServer = &http.Server{
+		Addr:              cfg.ListenAddress,
+		Handler:           mux,
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+
+	return s
+}
+
+func (s *Server) Run(ctx context.Context) error {
+	defer func() {
+		s.executor.Stop()
+		s.cache.LogSummary()
+	}()
+
+	errServe := make(chan error, 1)
+
+	go func() {
+		errServe <- s.httpServer.ListenAndServe()
+	}()
+
+	logger.Info("Server is listening on " + s.cfg.ListenAddress)
+
+	select {
+	case err := <-errServe:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	err := s.httpServer.Shutdown(shutdownCtx)
+
+	logger.Info("Server has finished")
+
+	return err
+}
+
+func (s *Server) handleClassify(w http.ResponseWriter, r *http.Request) {
+	request := classifyRequest{}
+
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize)).Decode(&request); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{err.Error()})
+		return
+	}
+
+	switch {
+	case request.Query != "" && len(request.Queries) > 0:
+		writeJSON(w, http.StatusBadRequest, errorResponse{ErrBothQueries.Error()})
+	case request.Query != "":
+		result := s.classify(r.Context(), request.Query)
+		status := http.StatusOK
+
+		if result.Error != "" {
+			status = http.StatusBadGateway
+		}
+
+		writeJSON(w, status, result)
+	case len(request.Queries) > s.cfg.MaxBatchSize:
+		writeJSON(w, http.StatusBadRequest, errorResponse{ErrWrapBatchSize(len(request.Queries), s.cfg.MaxBatchSize).Error()})
+	case len(request.Queries) > 0:
+		writeJSON(w, http.StatusOK, batchResponse{s.classifyBatch(r.Context(), request.Queries)})
+	default:
+		writeJSON(w, http.StatusBadRequest, errorResponse{ErrEmptyRequest.Error()})
+	}
+}
+
+// classifyBatch keeps order of queries, errors are returned per query
+func (s *Server) classifyBatch(ctx context.Context, queries []string) []classifyResult {
+	errGroup := errgroup.Group{}
+	errGroup.SetLimit(s.cfg.GoErrGroupLimiter)
+
+	results := make([]classifyResult, len(queries))
+
+	for i, query := range queries {
+		errGroup.Go(func() error {
+			results[i] = s.classify(ctx, query)
+			return nil
+		})
+	}
+
+	_ = errGroup.Wait()
+
+	return results
+}
+
+func (s *Server) classify(ctx context.Context, query string) classifyResult {
+	result := classifyResult{Query: query}
+	body, err := s.executor.Classify(ctx, query)
+
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	classification, err := saver.Classify(body)
+
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Name = classification.Name
+	result.Category = classification.Category
+	result.CatalogValue = classification.CatalogValue
+	result.Presets = classification.Presets
+	result.Params = classification.Params
+
+	return result
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		logger.Error("Response hasn't been written: " + err.Error())
+	}
+}